@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Join buttons on announcement cards look like "join:<event id>".
+const joinButtonPrefix = "join:"
+
+// announcementCard renders the short public card for an event.
+func announcementCard(ev *Event) *discordgo.MessageSend {
+	when := "TBD"
+	if ev.Date != nil {
+		when = fmt.Sprintf("<t:%d:f>", ev.Date.Unix())
+	}
+	content := fmt.Sprintf("%s **%s**\n:date: %s\n:round_pushpin: %s\nOrganized by <@%s>. Click Join to get access to the event channel.",
+		ev.Emoji, ev.Title, when, ev.Location, ev.AuthorID)
+	return &discordgo.MessageSend{
+		Content: content,
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Join", Style: discordgo.PrimaryButton, CustomID: fmt.Sprintf("%s%d", joinButtonPrefix, ev.ID)},
+			}},
+		},
+		// the card shouldn't ping the organizer every time it's posted
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+}
+
+// postAnnouncement posts the event's card in channelID and remembers it so
+// later edits and cancellation can find it.
+func postAnnouncement(s *discordgo.Session, ev *Event, channelID string) error {
+	sent, err := s.ChannelMessageSendComplex(channelID, announcementCard(ev))
+	if err != nil {
+		return err
+	}
+	if err := UpdateEventFieldByChannel(ev.ChannelID, "announce_channel_id", channelID); err != nil {
+		return err
+	}
+	return UpdateEventFieldByChannel(ev.ChannelID, "announce_message_id", sent.ID)
+}
+
+// syncAnnouncement keeps the event's card in step with the event: edited to
+// match, or removed once the event is cancelled.
+func syncAnnouncement(s *discordgo.Session, ev *Event) {
+	if ev.AnnounceMessageID == "" {
+		return
+	}
+	if ev.Cancelled() {
+		if err := s.ChannelMessageDelete(ev.AnnounceChannelID, ev.AnnounceMessageID); err != nil {
+			log.Printf("Failed to remove announcement card: %v", err)
+		}
+		if err := UpdateEventFieldByChannel(ev.ChannelID, "announce_message_id", ""); err != nil {
+			log.Printf("Failed to clear announcement card: %v", err)
+		}
+		return
+	}
+	card := announcementCard(ev)
+	if _, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:              ev.AnnounceMessageID,
+		Channel:         ev.AnnounceChannelID,
+		Content:         &card.Content,
+		AllowedMentions: card.AllowedMentions,
+	}); err != nil {
+		log.Printf("Failed to update announcement card: %v", err)
+	}
+}
+
+func handleJoinButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionMessageComponent {
+		return
+	}
+	customID := i.MessageComponentData().CustomID
+	if !strings.HasPrefix(customID, joinButtonPrefix) || i.Member == nil {
+		return
+	}
+	eventID, err := strconv.ParseInt(strings.TrimPrefix(customID, joinButtonPrefix), 10, 64)
+	if err != nil {
+		return
+	}
+	reply := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: content, Flags: discordgo.MessageFlagsEphemeral},
+		})
+	}
+	userID := i.Member.User.ID
+
+	ev, err := GetEventByID(eventID)
+	if err != nil {
+		reply("Could not find the event record.")
+		return
+	}
+	if ev.Cancelled() {
+		reply("This event has been cancelled.")
+		return
+	}
+	// Announcing a private event opens it to anyone who clicks, but a
+	// role-restricted one stays limited to its roles.
+	if ev.Visibility == "roles" && !canViewEvent(ev, i.Member) {
+		reply("This event is only open to certain roles.")
+		return
+	}
+	if err := inviteToEvent(s, ev, EventInvite{TargetID: userID, TargetType: "user"}, userID); err != nil {
+		log.Printf("Failed to join event: %v", err)
+		reply("Failed to join the event.")
+		return
+	}
+	// Only pencil them in as maybe when they haven't answered already.
+	if ev.JoinAsMaybe && !ev.RSVPClosed() {
+		if current, err := GetUserResponse(ev.ID, userID); err == nil && current == "" {
+			if _, err := UpsertResponse(ev.ID, userID, userID, "maybe", -1); err != nil {
+				log.Printf("Failed to RSVP maybe on join: %v", err)
+			}
+		}
+	}
+	refreshEventMessage(s, ev)
+	reply(fmt.Sprintf("You're in! Head over to <#%s>.", ev.ChannelID))
+}
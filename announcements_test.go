@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestAnnouncementCard(t *testing.T) {
+	date := time.Unix(1700000000, 0)
+	tests := []struct {
+		name string
+		ev   *Event
+		want string
+	}{
+		{name: "dated", ev: &Event{ID: 7, Emoji: ":tada:", Title: "Party", Location: "Home", AuthorID: "1", Date: &date}, want: "<t:1700000000:f>"},
+		{name: "undated", ev: &Event{ID: 7, Emoji: ":tada:", Title: "Party", Location: "Home", AuthorID: "1"}, want: ":date: TBD"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := announcementCard(tt.ev)
+			if !strings.Contains(card.Content, tt.want) || !strings.Contains(card.Content, "**Party**") {
+				t.Errorf("card content %q doesn't show %q", card.Content, tt.want)
+			}
+			row := card.Components[0].(discordgo.ActionsRow)
+			if id := row.Components[0].(discordgo.Button).CustomID; id != "join:7" {
+				t.Errorf("join button id = %q, want %q", id, "join:7")
+			}
+		})
+	}
+}
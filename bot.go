@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"log"
+	"os"
 	"strings"
 
 	"github.com/bwmarrin/discordgo"
@@ -15,7 +16,16 @@ func runBot(token, guildID string) error {
 	}
 
 	dg.AddHandler(onReady)
+	dg.AddHandler(func(s *discordgo.Session, r *discordgo.Ready) {
+		// catch up on "Interested" clicks on scheduled event mirrors
+		go reconcileScheduledEvents(s, guildID)
+	})
 	dg.AddHandler(onMessageCreate)
+	dg.AddHandler(onMessageReactionAdd)
+	dg.AddHandler(onMessageReactionRemove)
+	dg.AddHandler(onGuildScheduledEventUserAdd)
+	dg.AddHandler(onGuildScheduledEventUserRemove)
+	dg.AddHandler(onGuildScheduledEventDelete)
 	dg.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		// Log commands and modal submits for auditing
 		switch i.Type {
@@ -58,13 +68,45 @@ func runBot(token, guildID string) error {
 
 		// delegate to specific handlers
 		handleEventCommand(s, i)
+		handleEventWizardCommand(s, i)
+		handleEventWizardModal(s, i)
+		handleEventWizardComponents(s, i)
+		handlePendingEventButtons(s, i)
+		handlePendingEventModal(s, i)
+		handlePresetCommand(s, i)
+		handlePresetAutocomplete(s, i)
+		handleEventCloneCommand(s, i)
+		handleDatePollCommand(s, i)
+		handleDatePollButton(s, i)
 		handleChangeNameCommand(s, i)
 		handleChangeDateCommand(s, i)
 		handleChangeLocationCommand(s, i)
 		handleChangePriceCommand(s, i)
 		handleChangeNotesCommand(s, i)
 		handleChangeEmojiCommand(s, i)
+		handleChangeCapacityCommand(s, i)
+		handleChangeMaxGuestsCommand(s, i)
+		handleChangeRSVPDeadlineCommand(s, i)
+		handleChangeVisibilityCommand(s, i)
 		handleRSVPCommand(s, i)
+		handleRSVPButton(s, i)
+		handleRSVPCommentModal(s, i)
+		handleRSVPHistoryCommand(s, i)
+		handleProxyConfirmButton(s, i)
+		handleSettingsCommand(s, i)
+		handleCohostCommand(s, i)
+		handleInviteCommands(s, i)
+		handleJoinButton(s, i)
+		handleCancelEventCommand(s, i)
+		handleEventsListCommand(s, i)
+		handleEventsPageButton(s, i)
+		handleMyEventsCommand(s, i)
+		handleMyEventsButton(s, i)
+		handleCalendarFeedCommand(s, i)
+		handleEventICSCommand(s, i)
+		handleICSButton(s, i)
+		handleImportEventsCommand(s, i)
+		handleImportButtons(s, i)
 		handleHelpCommand(s, i)
 		handlePokerCommands(s, i)
 	})
@@ -77,22 +119,49 @@ func runBot(token, guildID string) error {
 
 	// Register slash commands (after opening so s.State is available)
 	registerEventCreation(dg, guildID)
+	registerEventWizard(dg, guildID)
+	registerPresets(dg, guildID)
+	registerEventClone(dg, guildID)
+	registerDatePoll(dg, guildID)
 	registerEventEditing(dg, guildID)
 	registerChangeDate(dg, guildID)
 	registerChangeLocation(dg, guildID)
 	registerChangePrice(dg, guildID)
 	registerChangeNotes(dg, guildID)
 	registerChangeEmoji(dg, guildID)
+	registerChangeCapacity(dg, guildID)
+	registerChangeMaxGuests(dg, guildID)
+	registerChangeRSVPDeadline(dg, guildID)
+	registerChangeVisibility(dg, guildID)
 	registerRSVP(dg, guildID)
+	registerRSVPHistory(dg, guildID)
+	registerSettings(dg, guildID)
+	registerCohost(dg, guildID)
+	registerInvites(dg, guildID)
+	registerCancelEvent(dg, guildID)
+	registerEventsList(dg, guildID)
+	registerMyEvents(dg, guildID)
+	registerCalendarFeed(dg, guildID)
+	registerEventICS(dg, guildID)
+	registerImportEvents(dg, guildID)
 	registerHelp(dg, guildID)
 	registerPokerCommands(dg, guildID)
 
+	go runScheduledEventLifecycle(dg, guildID)
+
+	// Optional iCalendar feeds
+	if addr := os.Getenv("CALENDAR_ADDR"); addr != "" {
+		go startCalendarServer(dg, addr, guildID)
+	}
+
 	log.Println("Bot is now running. Press CTRL+C to exit.")
 	select {} // Block forever
 }
 
 func onReady(s *discordgo.Session, event *discordgo.Ready) {
 	log.Printf("%s has connected to Discord!", s.State.User.String())
+	// catch up on reaction RSVPs made while we were offline
+	go reconcileReactionRSVPs(s)
 }
 
 func onMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Optional CalDAV push: when CALDAV_URL points at a calendar collection
+// (e.g. https://cloud.example.com/remote.php/dav/calendars/bot/events/),
+// public events are PUT there as they're created and edited and DELETEd
+// when cancelled or made private. CALDAV_USERNAME and CALDAV_PASSWORD are
+// sent as basic auth. Any server speaking plain WebDAV PUT/GET/DELETE with
+// ETags works, so a local Radicale instance is enough for trying it out.
+//
+// The bot is the source of truth: if the copy on the server changed since
+// the last push (the ETag no longer matches), the bot's version replaces it
+// and the conflict is logged.
+
+// caldav is nil when CalDAV sync isn't configured.
+var caldav *caldavClient
+
+// caldavMu serialises pushes so concurrent edits to an event don't race on
+// its ETag.
+var caldavMu sync.Mutex
+
+type caldavClient struct {
+	collection string // always ends in "/"
+	username   string
+	password   string
+	http       *http.Client
+}
+
+// initCalDAV reads the CalDAV settings from the environment.
+func initCalDAV() {
+	url := os.Getenv("CALDAV_URL")
+	if url == "" {
+		return
+	}
+	if !strings.HasSuffix(url, "/") {
+		url += "/"
+	}
+	caldav = &caldavClient{
+		collection: url,
+		username:   os.Getenv("CALDAV_USERNAME"),
+		password:   os.Getenv("CALDAV_PASSWORD"),
+		http:       &http.Client{Timeout: 15 * time.Second},
+	}
+	log.Printf("CalDAV sync enabled for %s", url)
+}
+
+// do sends a request with the given preconditions and returns the response
+// status and ETag.
+func (c *caldavClient) do(method, href, body string, header map[string]string) (int, string, error) {
+	var rd io.Reader
+	if body != "" {
+		rd = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, href, rd)
+	if err != nil {
+		return 0, "", err
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "text/calendar; charset=utf-8")
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, resp.Header.Get("ETag"), nil
+}
+
+// put stores body at href. With an etag the write only succeeds if the
+// server copy is unchanged; without one only if there's no copy yet, unless
+// exists says an earlier push put it there. On a conflict the current server
+// copy is overwritten, or recreated if it was deleted. It returns the new
+// ETag, fetched separately when the PUT response doesn't carry one; it is
+// only empty if the server doesn't do ETags at all.
+func (c *caldavClient) put(href, etag, body string, exists bool) (string, error) {
+	var cond map[string]string
+	switch {
+	case etag != "":
+		cond = map[string]string{"If-Match": etag}
+	case !exists:
+		cond = map[string]string{"If-None-Match": "*"}
+	}
+	status, newETag, err := c.do(http.MethodPut, href, body, cond)
+	if err != nil {
+		return "", err
+	}
+	if status == http.StatusPreconditionFailed {
+		remote, exists, rerr := c.currentETag(href)
+		if rerr != nil {
+			return "", rerr
+		}
+		log.Printf("CalDAV conflict on %s; replacing the server copy", href)
+		switch {
+		case !exists:
+			cond = map[string]string{"If-None-Match": "*"}
+		case remote != "":
+			cond = map[string]string{"If-Match": remote}
+		default:
+			// the server doesn't do ETags, so overwrite unconditionally
+			cond = nil
+		}
+		status, newETag, err = c.do(http.MethodPut, href, body, cond)
+		if err != nil {
+			return "", err
+		}
+	}
+	if status < 200 || status > 299 {
+		return "", fmt.Errorf("PUT %s: status %d", href, status)
+	}
+	if newETag == "" {
+		// servers may leave the ETag out when they alter what was stored;
+		// without it the next push would look like a conflict
+		if newETag, _, err = c.currentETag(href); err != nil {
+			log.Printf("Failed to fetch the ETag of %s: %v", href, err)
+		}
+	}
+	return newETag, nil
+}
+
+// delete removes href, overriding remote changes the same way put does. A
+// copy that's already gone counts as deleted.
+func (c *caldavClient) delete(href, etag string) error {
+	var cond map[string]string
+	if etag != "" {
+		cond = map[string]string{"If-Match": etag}
+	}
+	status, _, err := c.do(http.MethodDelete, href, "", cond)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusPreconditionFailed {
+		log.Printf("CalDAV conflict on %s; deleting anyway", href)
+		status, _, err = c.do(http.MethodDelete, href, "", nil)
+		if err != nil {
+			return err
+		}
+	}
+	if status == http.StatusNotFound || (status >= 200 && status <= 299) {
+		return nil
+	}
+	return fmt.Errorf("DELETE %s: status %d", href, status)
+}
+
+// currentETag fetches the server's ETag for href and whether it exists.
+func (c *caldavClient) currentETag(href string) (string, bool, error) {
+	status, etag, err := c.do(http.MethodGet, href, "", nil)
+	if err != nil {
+		return "", false, err
+	}
+	if status == http.StatusNotFound {
+		return "", false, nil
+	}
+	if status < 200 || status > 299 {
+		return "", false, fmt.Errorf("GET %s: status %d", href, status)
+	}
+	return etag, true, nil
+}
+
+// syncCalDAV pushes the event's current state to the CalDAV collection.
+// It reloads the event so it always works from the latest ETag, and is safe
+// to run in the background.
+func syncCalDAV(s *discordgo.Session, eventID int64) {
+	if caldav == nil {
+		return
+	}
+	caldavMu.Lock()
+	defer caldavMu.Unlock()
+	ev, err := GetEventByID(eventID)
+	if err != nil {
+		log.Printf("Failed to load event for CalDAV sync: %v", err)
+		return
+	}
+
+	if ev.Visibility != "public" || ev.Cancelled() || ev.Date == nil {
+		if ev.CalDAVHref == "" {
+			return
+		}
+		if err := caldav.delete(ev.CalDAVHref, ev.CalDAVETag); err != nil {
+			log.Printf("Failed to delete event from CalDAV: %v", err)
+			return
+		}
+		if err := SetEventCalDAV(ev.ID, "", ""); err != nil {
+			log.Printf("Failed to clear CalDAV state: %v", err)
+		}
+		return
+	}
+
+	href := ev.CalDAVHref
+	if href == "" {
+		href = fmt.Sprintf("%sevent-%d.ics", caldav.collection, ev.ID)
+	}
+	cal := newICSCalendar("")
+	cal.addEvent(ev, eventGuildID(s, ev))
+	etag, err := caldav.put(href, ev.CalDAVETag, cal.String(), ev.CalDAVHref != "")
+	if err != nil {
+		log.Printf("Failed to push event to CalDAV: %v", err)
+		return
+	}
+	if err := SetEventCalDAV(ev.ID, href, etag); err != nil {
+		log.Printf("Failed to store CalDAV state: %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeCalDAV is an in-memory collection honouring If-Match and
+// If-None-Match the way a CalDAV server does.
+type fakeCalDAV struct {
+	mu       sync.Mutex
+	bodies   map[string]string
+	etags    map[string]string
+	next     int
+	putETags bool // whether PUT responses carry the new ETag
+	requests []string
+}
+
+func newFakeCalDAV(t *testing.T) (*fakeCalDAV, *caldavClient) {
+	f := &fakeCalDAV{bodies: map[string]string{}, etags: map[string]string{}, putETags: true}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, &caldavClient{collection: srv.URL + "/cal/", http: srv.Client()}
+}
+
+// edit changes the server copy behind the bot's back.
+func (f *fakeCalDAV) edit(path, body string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.next++
+	f.bodies[path], f.etags[path] = body, fmt.Sprintf(`"%d"`, f.next)
+}
+
+func (f *fakeCalDAV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.Header.Get("If-Match")+r.Header.Get("If-None-Match"))
+	etag, exists := f.etags[r.URL.Path]
+	if m := r.Header.Get("If-Match"); m != "" && (!exists || m != etag) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	if r.Header.Get("If-None-Match") == "*" && exists {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		io.WriteString(w, f.bodies[r.URL.Path])
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.next++
+		f.bodies[r.URL.Path], f.etags[r.URL.Path] = string(body), fmt.Sprintf(`"%d"`, f.next)
+		if f.putETags {
+			w.Header().Set("ETag", f.etags[r.URL.Path])
+		}
+		if exists {
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			w.WriteHeader(http.StatusCreated)
+		}
+	case http.MethodDelete:
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.bodies, r.URL.Path)
+		delete(f.etags, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestCalDAVPut(t *testing.T) {
+	const path = "/cal/event-1.ics"
+	tests := []struct {
+		name     string
+		putETags bool
+		// remoteEdit changes the server copy between the create and the update
+		remoteEdit bool
+		want       []string // requests after the create
+	}{
+		{name: "update with If-Match", putETags: true, want: []string{`PUT "1"`}},
+		{name: "conflict replaces the server copy", putETags: true, remoteEdit: true, want: []string{`PUT "1"`, "GET ", `PUT "2"`}},
+		{name: "missing ETag is fetched", putETags: false, want: []string{`PUT "1"`, "GET "}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, c := newFakeCalDAV(t)
+			f.putETags = tt.putETags
+			href := c.collection + "event-1.ics"
+
+			etag, err := c.put(href, "", "v1", false)
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			if etag != `"1"` {
+				t.Fatalf("create returned ETag %q, want %q", etag, `"1"`)
+			}
+			if f.bodies[path] != "v1" {
+				t.Fatalf("server has %q after create", f.bodies[path])
+			}
+			if tt.remoteEdit {
+				f.edit(path, "edited elsewhere")
+			}
+			f.requests = nil
+
+			etag, err = c.put(href, etag, "v2", true)
+			if err != nil {
+				t.Fatalf("update: %v", err)
+			}
+			if f.bodies[path] != "v2" {
+				t.Errorf("server has %q after update, want v2", f.bodies[path])
+			}
+			if etag != f.etags[path] {
+				t.Errorf("update returned ETag %q, server has %q", etag, f.etags[path])
+			}
+			if strings.Join(f.requests, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("requests = %q, want %q", f.requests, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalDAVPutWithoutStoredETag(t *testing.T) {
+	// a copy pushed to a server that never reported an ETag is overwritten
+	// without a precondition instead of tripping If-None-Match
+	f, c := newFakeCalDAV(t)
+	href := c.collection + "event-1.ics"
+	f.edit("/cal/event-1.ics", "v1")
+	f.requests = nil
+	if _, err := c.put(href, "", "v2", true); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if want := []string{"PUT "}; strings.Join(f.requests, ",") != strings.Join(want, ",") {
+		t.Errorf("requests = %q, want %q", f.requests, want)
+	}
+}
+
+func TestCalDAVDelete(t *testing.T) {
+	const path = "/cal/event-1.ics"
+	tests := []struct {
+		name       string
+		create     bool
+		remoteEdit bool
+	}{
+		{name: "with matching ETag", create: true},
+		{name: "after a remote edit", create: true, remoteEdit: true},
+		{name: "already gone", create: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, c := newFakeCalDAV(t)
+			href := c.collection + "event-1.ics"
+			etag := ""
+			if tt.create {
+				var err error
+				if etag, err = c.put(href, "", "v1", false); err != nil {
+					t.Fatalf("create: %v", err)
+				}
+			}
+			if tt.remoteEdit {
+				f.edit(path, "edited elsewhere")
+			}
+			if err := c.delete(href, etag); err != nil {
+				t.Fatalf("delete: %v", err)
+			}
+			if _, ok := f.bodies[path]; ok {
+				t.Errorf("server copy still exists")
+			}
+		})
+	}
+}
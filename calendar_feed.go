@@ -0,0 +1,171 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// The calendar feeds are served when CALENDAR_ADDR (e.g. ":8080") is set;
+// CALENDAR_BASE_URL is the public URL they're reachable at, used for the
+// links handed out by /calendar_feed.
+//
+//	/calendar/<guild id>.ics     public events of the guild
+//	/calendar/user/<token>.ics   events the token's owner said yes or maybe to
+const calendarPathPrefix = "/calendar/"
+
+// startCalendarServer serves the feeds until the listener fails.
+func startCalendarServer(s *discordgo.Session, addr, guildID string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(calendarPathPrefix, func(w http.ResponseWriter, r *http.Request) {
+		serveCalendarFeed(s, w, r, guildID)
+	})
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("Serving calendar feeds on %s", addr)
+	if err := srv.ListenAndServe(); err != nil {
+		log.Printf("Calendar server stopped: %v", err)
+	}
+}
+
+func serveCalendarFeed(s *discordgo.Session, w http.ResponseWriter, r *http.Request, guildID string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, calendarPathPrefix)
+	if !strings.HasSuffix(path, ".ics") {
+		http.NotFound(w, r)
+		return
+	}
+	path = strings.TrimSuffix(path, ".ics")
+
+	var cal *icsCalendar
+	if token, ok := strings.CutPrefix(path, "user/"); ok {
+		userID, err := GetCalendarTokenUser(token)
+		if err == sql.ErrNoRows {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to look up calendar token: %v", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		events, err := ListUserEvents(userID)
+		if err != nil {
+			log.Printf("Failed to list user events for feed: %v", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		cal = newICSCalendar("My events")
+		cutoff := time.Now().Add(-calendarFeedHistory)
+		for _, ue := range events {
+			if end := ue.End(); end != nil && end.Before(cutoff) {
+				continue
+			}
+			// only what /events would show them, e.g. not events they were
+			// uninvited from after answering
+			if ue.GuildID != guildID {
+				continue
+			}
+			if visible, err := canUserViewEvent(s, ue.Event, guildID, userID); err != nil || !visible {
+				continue
+			}
+			cal.addEvent(ue.Event, guildID)
+		}
+	} else if path == guildID {
+		events, err := ListPublicFeedEvents(guildID)
+		if err != nil {
+			log.Printf("Failed to list events for feed: %v", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		cal = newICSCalendar("Events")
+		for _, ev := range events {
+			cal.addEvent(ev, guildID)
+		}
+	} else {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := w.Write([]byte(cal.String())); err != nil {
+		log.Printf("Failed to write calendar feed: %v", err)
+	}
+}
+
+func registerCalendarFeed(s *discordgo.Session, guildID string) {
+	cmd := &discordgo.ApplicationCommand{
+		Name:        "calendar_feed",
+		Description: "Get calendar subscription links for this server's events and your own",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "reset",
+				Description: "Replace your personal link, e.g. if you shared it by accident",
+				Required:    false,
+			},
+		},
+	}
+	_, err := s.ApplicationCommandCreate(s.State.User.ID, guildID, cmd)
+	if err != nil {
+		log.Printf("Cannot create '/calendar_feed' command: %v", err)
+	}
+}
+
+func handleCalendarFeedCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	if i.ApplicationCommandData().Name != "calendar_feed" {
+		return
+	}
+	if i.Member == nil {
+		return
+	}
+	var reset bool
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name == "reset" {
+			reset = opt.BoolValue()
+		}
+	}
+	reply := func(msg string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: msg, Flags: discordgo.MessageFlagsEphemeral},
+		})
+	}
+	base := strings.TrimSuffix(os.Getenv("CALENDAR_BASE_URL"), "/")
+	if base == "" || os.Getenv("CALENDAR_ADDR") == "" {
+		reply("Calendar feeds aren't set up on this bot.")
+		return
+	}
+	token, err := GetCalendarToken(i.Member.User.ID, reset)
+	if err != nil {
+		log.Printf("Failed to get calendar token: %v", err)
+		reply("Failed to get your calendar link.")
+		return
+	}
+	msg := fmt.Sprintf("**Server events** (public events):\n<%s%s%s.ics>\n", base, calendarPathPrefix, i.GuildID) +
+		fmt.Sprintf("**Your events** (yes/maybe RSVPs; keep this link private):\n<%s%suser/%s.ics>\n", base, calendarPathPrefix, token) +
+		"Subscribe to a link in your calendar app (\"Add calendar from URL\")."
+	if reset {
+		msg += "\nYour previous personal link no longer works."
+	}
+	reply(msg)
+}
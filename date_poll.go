@@ -0,0 +1,431 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Date polls let an event's members vote on candidate times before the
+// organizer settles on one. Each option gets a row of buttons,
+// "datepoll:<poll id>:<position>:<yes|maybe|no>", where maybe is shown as
+// "if need be". Finalizing sets the event's date and turns the votes on the
+// chosen time into RSVPs.
+const datePollButtonPrefix = "datepoll:"
+
+const (
+	// maxDatePollOptions is one option per row of buttons.
+	maxDatePollOptions = 5
+	// datePollLength keeps the voter grid under Discord's message limit.
+	datePollLength = 1800
+)
+
+// datePollVotes are the vote kinds and how they show in the tally.
+var datePollVotes = []struct{ Vote, Label, Emoji string }{
+	{"yes", "Works", "✅"},
+	{"maybe", "If need be", "🤷"},
+	{"no", "Can't", "❌"},
+}
+
+var datePollKeycaps = []string{"1️⃣", "2️⃣", "3️⃣", "4️⃣", "5️⃣"}
+
+func registerDatePoll(s *discordgo.Session, guildID string) {
+	minOption, maxOption := 1.0, float64(maxDatePollOptions)
+	cmd := &discordgo.ApplicationCommand{
+		Name:        "date_poll",
+		Description: "Let people vote on when the event in the current channel should happen",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "start",
+				Description: "Post a poll with candidate times",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "times",
+						Description: "2 to 5 times separated by ; (e.g. 2025-05-02 19:00; 2025-05-03 14:00 to 17:00)",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "finalize",
+				Description: "Pick the winning time; votes on it become RSVPs",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "option",
+						Description: "Number of the chosen time in the poll",
+						Required:    true,
+						MinValue:    &minOption,
+						MaxValue:    maxOption,
+					},
+				},
+			},
+		},
+	}
+	_, err := s.ApplicationCommandCreate(s.State.User.ID, guildID, cmd)
+	if err != nil {
+		log.Printf("Cannot create '/date_poll' command: %v", err)
+	}
+}
+
+func handleDatePollCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	if i.ApplicationCommandData().Name != "date_poll" {
+		return
+	}
+	options := i.ApplicationCommandData().Options
+	if len(options) == 0 {
+		return
+	}
+	ev := requireEventManager(s, i)
+	if ev == nil {
+		return
+	}
+	reply := func(msg string, flags discordgo.MessageFlags) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: msg, Flags: flags},
+		})
+	}
+	if ev.Cancelled() {
+		reply("This event has been cancelled.", discordgo.MessageFlagsEphemeral)
+		return
+	}
+	sub := options[0]
+
+	switch sub.Name {
+	case "start":
+		var timesStr string
+		for _, opt := range sub.Options {
+			if opt.Name == "times" {
+				timesStr = opt.StringValue()
+			}
+		}
+		pollOptions, err := parseDatePollOptions(timesStr)
+		if err != nil {
+			reply("Please "+err.Error()+".", discordgo.MessageFlagsEphemeral)
+			return
+		}
+		if _, err := GetOpenDatePoll(ev.ID); err == nil {
+			reply("This event already has an open date poll; finalize it first.", discordgo.MessageFlagsEphemeral)
+			return
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Failed to check for an open date poll: %v", err)
+			reply("Failed to create the poll.", discordgo.MessageFlagsEphemeral)
+			return
+		}
+		pollID, err := CreateDatePoll(ev.ID, i.Member.User.ID, pollOptions)
+		if err != nil {
+			log.Printf("Failed to create date poll: %v", err)
+			reply("Failed to create the poll.", discordgo.MessageFlagsEphemeral)
+			return
+		}
+		poll, err := GetDatePoll(pollID)
+		if err != nil {
+			log.Printf("Failed to load date poll: %v", err)
+			reply("Failed to create the poll.", discordgo.MessageFlagsEphemeral)
+			return
+		}
+		content, components := datePollMessage(ev, poll, nil, nil)
+		sent, err := s.ChannelMessageSendComplex(ev.ChannelID, &discordgo.MessageSend{
+			Content:         content,
+			Components:      components,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		})
+		if err != nil {
+			log.Printf("Failed to post date poll: %v", err)
+			reply("Failed to post the poll.", discordgo.MessageFlagsEphemeral)
+			return
+		}
+		if err := SetDatePollMessage(pollID, sent.ID); err != nil {
+			log.Printf("Failed to record date poll message: %v", err)
+		}
+		reply("Date poll posted. Use `/date_poll finalize` once people have voted.", discordgo.MessageFlagsEphemeral)
+
+	case "finalize":
+		position := 0
+		for _, opt := range sub.Options {
+			if opt.Name == "option" {
+				position = int(opt.IntValue())
+			}
+		}
+		poll, err := GetOpenDatePoll(ev.ID)
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				log.Printf("Failed to load date poll: %v", err)
+			}
+			reply("There's no open date poll for this event.", discordgo.MessageFlagsEphemeral)
+			return
+		}
+		if position < 1 || position > len(poll.Options) {
+			reply(fmt.Sprintf("Please pick an option between 1 and %d.", len(poll.Options)), discordgo.MessageFlagsEphemeral)
+			return
+		}
+		rsvps, err := finalizeDatePoll(s, ev, poll, position)
+		if errors.Is(err, ErrDatePollClosed) {
+			reply("This date poll has already been finalized.", discordgo.MessageFlagsEphemeral)
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to finalize date poll: %v", err)
+			reply("Failed to set the event date.", discordgo.MessageFlagsEphemeral)
+			return
+		}
+		chosen := poll.Options[position-1]
+		reply(fmt.Sprintf(":calendar_spiral: The poll is closed: the event is on <t:%d:F>. %d vote(s) on that time became RSVPs.", chosen.Start.Unix(), rsvps), 0)
+	}
+}
+
+// parseDatePollOptions reads the candidate times, each in any format the
+// flexible parser takes, including ranges.
+func parseDatePollOptions(timesStr string) ([]DatePollOption, error) {
+	var out []DatePollOption
+	for _, part := range strings.Split(timesStr, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		start, end, err := ParseFlexibleRange(part)
+		if err != nil {
+			return nil, fmt.Errorf("provide valid times separated by ; (couldn't read %q)", part)
+		}
+		out = append(out, DatePollOption{Position: len(out) + 1, Start: start, End: end})
+	}
+	if len(out) < 2 || len(out) > maxDatePollOptions {
+		return nil, fmt.Errorf("provide between 2 and %d times separated by ;", maxDatePollOptions)
+	}
+	return out, nil
+}
+
+// finalizeDatePoll closes the poll, moves the event to the chosen option and
+// turns the votes on it into RSVPs. It returns how many RSVPs were recorded.
+// Closing comes first so a second finalize stops at ErrDatePollClosed
+// instead of moving the event and recording the RSVPs again.
+func finalizeDatePoll(s *discordgo.Session, ev *Event, poll *DatePoll, position int) (int, error) {
+	if err := FinalizeDatePoll(poll.ID, position); err != nil {
+		return 0, err
+	}
+	poll.Chosen = position
+	chosen := poll.Options[position-1]
+	if err := UpdateEventDatesByChannel(ev.ChannelID, chosen.Start, chosen.End); err != nil {
+		return 0, err
+	}
+
+	voters, votes, err := GetDatePollVotes(poll.ID)
+	if err != nil {
+		log.Printf("Failed to load date poll votes: %v", err)
+	}
+	rsvps := 0
+	var promoted []string
+	for _, userID := range voters {
+		vote, ok := votes[userID][position]
+		if !ok {
+			continue
+		}
+		outcome, err := UpsertResponse(ev.ID, userID, userID, vote, -1)
+		if err != nil {
+			log.Printf("Failed to persist RSVP (date poll): %v", err)
+			continue
+		}
+		rsvps++
+		promoted = append(promoted, outcome.Promoted...)
+	}
+
+	if updated, err := GetEventByChannel(ev.ChannelID); err == nil {
+		refreshEventMessage(s, updated)
+		syncEventMirrors(s, updated)
+		ev = updated
+	}
+	announcePromotions(s, ev.ChannelID, promoted)
+	if poll.MessageID != "" {
+		content, components := datePollMessage(ev, poll, voters, votes)
+		_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			Channel:         ev.ChannelID,
+			ID:              poll.MessageID,
+			Content:         &content,
+			Components:      &components,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		})
+		if err != nil {
+			log.Printf("Failed to update date poll message: %v", err)
+		}
+	}
+	return rsvps, nil
+}
+
+// handleDatePollButton records a vote and redraws the tally.
+func handleDatePollButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionMessageComponent || i.Member == nil {
+		return
+	}
+	rest, ok := strings.CutPrefix(i.MessageComponentData().CustomID, datePollButtonPrefix)
+	if !ok {
+		return
+	}
+	parts := strings.Split(rest, ":")
+	if len(parts) != 3 {
+		return
+	}
+	pollID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return
+	}
+	position, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return
+	}
+	vote := parts[2]
+	if vote != "yes" && vote != "maybe" && vote != "no" {
+		return
+	}
+	ephemeral := func(msg string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: msg, Flags: discordgo.MessageFlagsEphemeral},
+		})
+	}
+	poll, err := GetDatePoll(pollID)
+	if err != nil {
+		ephemeral("Could not find this poll.")
+		return
+	}
+	if !poll.Open() {
+		ephemeral("This poll is closed.")
+		return
+	}
+	if position < 1 || position > len(poll.Options) {
+		return
+	}
+	ev, err := GetEventByID(poll.EventID)
+	if err != nil {
+		ephemeral("Could not find the event record.")
+		return
+	}
+	if err := ToggleDatePollVote(pollID, position, i.Member.User.ID, vote); err != nil {
+		log.Printf("Failed to record date poll vote: %v", err)
+		ephemeral("Failed to record your vote.")
+		return
+	}
+	voters, votes, err := GetDatePollVotes(pollID)
+	if err != nil {
+		log.Printf("Failed to load date poll votes: %v", err)
+	}
+	content, components := datePollMessage(ev, poll, voters, votes)
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:         content,
+			Components:      components,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+// datePollMessage renders the options with their totals and a grid of who
+// voted what, plus the vote buttons while the poll is open.
+func datePollMessage(ev *Event, poll *DatePoll, voters []string, votes map[string]map[int]string) (string, []discordgo.MessageComponent) {
+	var b strings.Builder
+	if poll.Open() {
+		fmt.Fprintf(&b, ":calendar_spiral: **When should we hold %s %s?**\n", ev.Emoji, ev.Title)
+		b.WriteString("Vote on every time: ✅ works · 🤷 if need be · ❌ can't. Click again to take a vote back.\n\n")
+	} else {
+		fmt.Fprintf(&b, ":calendar_spiral: **%s %s** — poll closed, option %d was chosen.\n\n", ev.Emoji, ev.Title, poll.Chosen)
+	}
+
+	// totals per option; the best has the most yeses, then if-need-bes
+	counts := map[int]map[string]int{}
+	for _, o := range poll.Options {
+		counts[o.Position] = map[string]int{}
+	}
+	for _, userID := range voters {
+		for position, vote := range votes[userID] {
+			if counts[position] != nil {
+				counts[position][vote]++
+			}
+		}
+	}
+	best := 0
+	for _, o := range poll.Options {
+		c := counts[o.Position]
+		if c["yes"] == 0 {
+			continue
+		}
+		if best == 0 || c["yes"] > counts[best]["yes"] || (c["yes"] == counts[best]["yes"] && c["maybe"] > counts[best]["maybe"]) {
+			best = o.Position
+		}
+	}
+	for _, o := range poll.Options {
+		c := counts[o.Position]
+		// voters need the actual date, not just "in 3 days"
+		dates := fmt.Sprintf("<t:%d:f>", o.Start.Unix())
+		if o.End != nil {
+			dates = formatEventDates(&Event{Date: &o.Start, EndDate: o.End})
+		}
+		fmt.Fprintf(&b, "%s %s — ✅ %d · 🤷 %d · ❌ %d", datePollKeycaps[o.Position-1], dates, c["yes"], c["maybe"], c["no"])
+		switch {
+		case o.Position == poll.Chosen:
+			b.WriteString(" 📌 **chosen**")
+		case poll.Open() && o.Position == best:
+			b.WriteString(" ⭐")
+		}
+		b.WriteString("\n")
+	}
+
+	if len(voters) > 0 {
+		b.WriteString("\n")
+		for _, o := range poll.Options {
+			b.WriteString(datePollKeycaps[o.Position-1] + " ")
+		}
+		b.WriteString("\n")
+		for n, userID := range voters {
+			if b.Len() > datePollLength {
+				fmt.Fprintf(&b, "_…and %d more._\n", len(voters)-n)
+				break
+			}
+			for _, o := range poll.Options {
+				cell := "➖"
+				for _, v := range datePollVotes {
+					if votes[userID][o.Position] == v.Vote {
+						cell = v.Emoji
+					}
+				}
+				b.WriteString(cell + " ")
+			}
+			b.WriteString("<@" + userID + ">\n")
+		}
+	}
+
+	components := []discordgo.MessageComponent{}
+	if poll.Open() {
+		for _, o := range poll.Options {
+			row := discordgo.ActionsRow{}
+			for _, v := range datePollVotes {
+				style := discordgo.SecondaryButton
+				switch v.Vote {
+				case "yes":
+					style = discordgo.SuccessButton
+				case "no":
+					style = discordgo.DangerButton
+				}
+				row.Components = append(row.Components, discordgo.Button{
+					Label:    fmt.Sprintf("%d: %s", o.Position, v.Label),
+					Style:    style,
+					CustomID: fmt.Sprintf("%s%d:%d:%s", datePollButtonPrefix, poll.ID, o.Position, v.Vote),
+					Emoji:    &discordgo.ComponentEmoji{Name: v.Emoji},
+				})
+			}
+			components = append(components, row)
+		}
+	}
+	return b.String(), components
+}
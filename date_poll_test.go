@@ -0,0 +1,47 @@
+package main
+
+import "testing"
+
+func TestParseDatePollOptions(t *testing.T) {
+	tests := []struct {
+		in       string
+		withEnd  []bool // whether each option has an end
+		wantFail bool
+	}{
+		{in: "2025-05-02 19:00; 2025-05-03 19:00", withEnd: []bool{false, false}},
+		{in: "2025-05-02 19:00 to 22:00;2025-05-03 19:00;", withEnd: []bool{true, false}},
+		{in: "2025-05-02; 2025-05-03; 2025-05-04; 2025-05-05; 2025-05-06", withEnd: []bool{false, false, false, false, false}},
+		{in: "2025-05-02 19:00", wantFail: true},
+		{in: "2025-05-02; 2025-05-03; 2025-05-04; 2025-05-05; 2025-05-06; 2025-05-07", wantFail: true},
+		{in: "2025-05-02; someday", wantFail: true},
+		{in: "", wantFail: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseDatePollOptions(tt.in)
+			if tt.wantFail {
+				if err == nil {
+					t.Fatalf("got %d options; want an error", len(got))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tt.withEnd) {
+				t.Fatalf("got %d options, want %d", len(got), len(tt.withEnd))
+			}
+			for n, o := range got {
+				if o.Position != n+1 {
+					t.Errorf("option %d has position %d", n, o.Position)
+				}
+				if (o.End != nil) != tt.withEnd[n] {
+					t.Errorf("option %d has end %v", n, o.End)
+				}
+			}
+			if want := central(t, "2025-05-02 00:00:00"); got[0].Start.Before(want) || !got[0].Start.Before(want.AddDate(0, 0, 1)) {
+				t.Errorf("first option starts %v, want on 2025-05-02", got[0].Start)
+			}
+		})
+	}
+}
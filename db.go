@@ -1,7 +1,10 @@
 package main
 
 import (
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
@@ -59,6 +62,262 @@ func InitDB() error {
 		return err
 	}
 
+	// Optional end of an event; NULL means the event has no explicit end.
+	_, err = db.Exec(`ALTER TABLE events ADD COLUMN IF NOT EXISTS end_date TIMESTAMPTZ`)
+	if err != nil {
+		return err
+	}
+
+	// Optional attendee limit; NULL means unlimited. A non-NULL waitlisted_at on a
+	// "yes" response means the user is queued rather than confirmed.
+	_, err = db.Exec(`ALTER TABLE events ADD COLUMN IF NOT EXISTS capacity INTEGER CHECK (capacity > 0)`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`ALTER TABLE event_responses ADD COLUMN IF NOT EXISTS waitlisted_at TIMESTAMPTZ`)
+	if err != nil {
+		return err
+	}
+
+	// Plus-ones per response, counted against capacity, and an optional
+	// per-event cap on them (NULL means no cap).
+	_, err = db.Exec(`ALTER TABLE event_responses ADD COLUMN IF NOT EXISTS guests INTEGER NOT NULL DEFAULT 0 CHECK (guests >= 0)`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`ALTER TABLE events ADD COLUMN IF NOT EXISTS max_guests INTEGER CHECK (max_guests >= 0)`)
+	if err != nil {
+		return err
+	}
+
+	// Free-text note attendees attach to their response ("arriving late").
+	_, err = db.Exec(`ALTER TABLE event_responses ADD COLUMN IF NOT EXISTS comment TEXT NOT NULL DEFAULT ''`)
+	if err != nil {
+		return err
+	}
+
+	// Append-only log of every RSVP change. actor_id differs from user_id for
+	// proxy RSVPs; a NULL new_response means the response was withdrawn.
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS event_response_history (
+        id BIGSERIAL PRIMARY KEY,
+        event_id BIGINT NOT NULL,
+        user_id TEXT NOT NULL,
+        actor_id TEXT NOT NULL,
+        old_response TEXT,
+        new_response TEXT,
+        old_guests INTEGER NOT NULL DEFAULT 0,
+        new_guests INTEGER NOT NULL DEFAULT 0,
+        changed_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+    )`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS event_response_history_event_idx ON event_response_history (event_id, changed_at)`)
+	if err != nil {
+		return err
+	}
+	// Whether the yes on either side of a change was on the waitlist, so
+	// promotions show up and the replayed headcount leaves the queue out.
+	_, err = db.Exec(`ALTER TABLE event_response_history ADD COLUMN IF NOT EXISTS old_waitlisted BOOLEAN NOT NULL DEFAULT FALSE`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`ALTER TABLE event_response_history ADD COLUMN IF NOT EXISTS new_waitlisted BOOLEAN NOT NULL DEFAULT FALSE`)
+	if err != nil {
+		return err
+	}
+
+	// Lifecycle status ('active' or 'cancelled') and the optional public
+	// announcement card for private events.
+	_, err = db.Exec(`ALTER TABLE events ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'active'`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`ALTER TABLE events ADD COLUMN IF NOT EXISTS announce_channel_id TEXT NOT NULL DEFAULT ''`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`ALTER TABLE events ADD COLUMN IF NOT EXISTS announce_message_id TEXT NOT NULL DEFAULT ''`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`ALTER TABLE events ADD COLUMN IF NOT EXISTS join_as_maybe BOOLEAN NOT NULL DEFAULT FALSE`)
+	if err != nil {
+		return err
+	}
+
+	// Who can see the event channel: 'public', 'private' (invite only) or
+	// 'roles' (visibility_roles, space-separated role IDs).
+	_, err = db.Exec(`ALTER TABLE events ADD COLUMN IF NOT EXISTS visibility TEXT NOT NULL DEFAULT 'private'`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`ALTER TABLE events ADD COLUMN IF NOT EXISTS visibility_roles TEXT NOT NULL DEFAULT ''`)
+	if err != nil {
+		return err
+	}
+
+	// Co-organizers who may manage an event alongside its author.
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS event_cohosts (
+        event_id BIGINT NOT NULL,
+        user_id TEXT NOT NULL,
+        added_by TEXT NOT NULL,
+        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY (event_id, user_id)
+    )`)
+	if err != nil {
+		return err
+	}
+
+	// Users and roles granted access to a private event channel.
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS event_invites (
+        event_id BIGINT NOT NULL,
+        target_id TEXT NOT NULL,
+        target_type TEXT NOT NULL CHECK (target_type IN ('user', 'role')),
+        invited_by TEXT NOT NULL,
+        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY (event_id, target_id)
+    )`)
+	if err != nil {
+		return err
+	}
+
+	// Per-guild settings; a missing row means the defaults.
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS guild_settings (
+        guild_id TEXT PRIMARY KEY,
+        open_proxy_rsvp BOOLEAN NOT NULL DEFAULT FALSE,
+        updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+    )`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`ALTER TABLE guild_settings ADD COLUMN IF NOT EXISTS announcements_channel_id TEXT NOT NULL DEFAULT ''`)
+	if err != nil {
+		return err
+	}
+
+	// Proxy RSVPs waiting for the target user to confirm; they don't count
+	// until confirmed. One pending request per user per event.
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS pending_proxy_rsvps (
+        id BIGSERIAL PRIMARY KEY,
+        event_id BIGINT NOT NULL,
+        user_id TEXT NOT NULL,
+        actor_id TEXT NOT NULL,
+        response_type TEXT NOT NULL,
+        guests INTEGER NOT NULL DEFAULT -1,
+        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+        UNIQUE (event_id, user_id)
+    )`)
+	if err != nil {
+		return err
+	}
+
+	// Optional RSVP cut-off; NULL means responses stay open.
+	_, err = db.Exec(`ALTER TABLE events ADD COLUMN IF NOT EXISTS rsvp_deadline TIMESTAMPTZ`)
+	if err != nil {
+		return err
+	}
+
+	// Whether reacting to the event message counts as an RSVP.
+	_, err = db.Exec(`ALTER TABLE events ADD COLUMN IF NOT EXISTS reaction_rsvp BOOLEAN NOT NULL DEFAULT FALSE`)
+	if err != nil {
+		return err
+	}
+
+	// iCalendar SEQUENCE, bumped whenever the time, place or status changes so
+	// subscribed calendars pick up the update.
+	_, err = db.Exec(`ALTER TABLE events ADD COLUMN IF NOT EXISTS sequence INTEGER NOT NULL DEFAULT 0`)
+	if err != nil {
+		return err
+	}
+
+	// Guild the event belongs to, so a guild's feed only lists its own events.
+	_, err = db.Exec(`ALTER TABLE events ADD COLUMN IF NOT EXISTS guild_id TEXT NOT NULL DEFAULT ''`)
+	if err != nil {
+		return err
+	}
+
+	// Mirror of the event in Discord's Scheduled Events, if any.
+	_, err = db.Exec(`ALTER TABLE events ADD COLUMN IF NOT EXISTS scheduled_event_id TEXT NOT NULL DEFAULT ''`)
+	if err != nil {
+		return err
+	}
+
+	// Copy of the event on the CalDAV collection, if any.
+	_, err = db.Exec(`ALTER TABLE events ADD COLUMN IF NOT EXISTS caldav_href TEXT NOT NULL DEFAULT ''`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`ALTER TABLE events ADD COLUMN IF NOT EXISTS caldav_etag TEXT NOT NULL DEFAULT ''`)
+	if err != nil {
+		return err
+	}
+
+	// Secret tokens for the per-user calendar feed.
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS calendar_tokens (
+        user_id TEXT PRIMARY KEY,
+        token TEXT NOT NULL UNIQUE,
+        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+    )`)
+	if err != nil {
+		return err
+	}
+
+	// Date polls: candidate times for an event and everyone's votes on them.
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS date_polls (
+        id BIGSERIAL PRIMARY KEY,
+        event_id BIGINT NOT NULL,
+        message_id TEXT NOT NULL DEFAULT '',
+        created_by TEXT NOT NULL,
+        chosen INTEGER,
+        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+    )`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS date_poll_options (
+        poll_id BIGINT NOT NULL,
+        position INTEGER NOT NULL,
+        starts_at TIMESTAMPTZ NOT NULL,
+        ends_at TIMESTAMPTZ,
+        PRIMARY KEY (poll_id, position)
+    )`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS date_poll_votes (
+        poll_id BIGINT NOT NULL,
+        position INTEGER NOT NULL,
+        user_id TEXT NOT NULL,
+        vote TEXT NOT NULL,
+        updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY (poll_id, position, user_id)
+    )`)
+	if err != nil {
+		return err
+	}
+
+	// Saved /event defaults, private to the user who saved them in a guild.
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS event_presets (
+        id BIGSERIAL PRIMARY KEY,
+        guild_id TEXT NOT NULL,
+        user_id TEXT NOT NULL,
+        name TEXT NOT NULL,
+        title TEXT NOT NULL DEFAULT '',
+        location TEXT NOT NULL DEFAULT '',
+        price TEXT NOT NULL DEFAULT '',
+        emoji TEXT NOT NULL DEFAULT '',
+        notes TEXT NOT NULL DEFAULT '',
+        visibility TEXT NOT NULL DEFAULT '',
+        visibility_roles TEXT NOT NULL DEFAULT '',
+        invitees TEXT NOT NULL DEFAULT '',
+        updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+        UNIQUE (guild_id, user_id, name)
+    )`)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -118,124 +377,1372 @@ func GetPokerLifetime(userID string) (count int, net float64, err error) {
 	return count, net, nil
 }
 
-// CreateEvent inserts a new event row. It returns the created id.
-func CreateEvent(channelID, messageID, emoji, title, location, price, authorID string, date time.Time) (int64, error) {
+// CreateEvent inserts ev with all its settings, along with its invites and
+// co-hosts, in one transaction, so an event is never left half set up. It
+// returns the created id.
+func CreateEvent(ev *Event, invites []EventInvite, cohosts []string) (int64, error) {
 	if db == nil {
 		return 0, fmt.Errorf("db not initialized")
 	}
+	for _, inv := range invites {
+		if inv.TargetType == "user" {
+			_ = upsertUser(inv.TargetID, "")
+		}
+	}
+	for _, id := range cohosts {
+		_ = upsertUser(id, "")
+	}
+	var dateArg, endArg, capArg, guestsArg, deadlineArg interface{}
+	if ev.Date != nil {
+		dateArg = *ev.Date
+	}
+	if ev.EndDate != nil {
+		endArg = *ev.EndDate
+	}
+	if ev.Capacity > 0 {
+		capArg = ev.Capacity
+	}
+	if ev.MaxGuests >= 0 {
+		guestsArg = ev.MaxGuests
+	}
+	if ev.Deadline != nil {
+		deadlineArg = *ev.Deadline
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
 	var id int64
-	q := `INSERT INTO events (discord_channel_id, discord_message_id, emoji, date, title, location, price, author_id)
-          VALUES ($1,$2,$3,$4,$5,$6,$7,$8) RETURNING id`
-	err := db.QueryRow(q, channelID, messageID, emoji, date, title, location, price, authorID).Scan(&id)
-	return id, err
+	q := `INSERT INTO events (guild_id, discord_channel_id, discord_message_id, emoji, date, end_date, title, location, price, description, author_id,
+              capacity, max_guests, rsvp_deadline, reaction_rsvp, join_as_maybe, visibility, visibility_roles)
+          VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18) RETURNING id`
+	err = tx.QueryRow(q, ev.GuildID, ev.ChannelID, ev.MessageID, ev.Emoji, dateArg, endArg, ev.Title, ev.Location, ev.Price, ev.Description, ev.AuthorID,
+		capArg, guestsArg, deadlineArg, ev.ReactionRSVP, ev.JoinAsMaybe, ev.Visibility, strings.Join(ev.VisibilityRoles, " ")).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	for _, inv := range invites {
+		if _, err := tx.Exec("INSERT INTO event_invites (event_id, target_id, target_type, invited_by) VALUES ($1,$2,$3,$4) ON CONFLICT DO NOTHING", id, inv.TargetID, inv.TargetType, ev.AuthorID); err != nil {
+			return 0, err
+		}
+	}
+	for _, userID := range cohosts {
+		if _, err := tx.Exec("INSERT INTO event_cohosts (event_id, user_id, added_by) VALUES ($1,$2,$3) ON CONFLICT DO NOTHING", id, userID, ev.AuthorID); err != nil {
+			return 0, err
+		}
+	}
+	return id, tx.Commit()
 }
 
 // Event represents an event row with fields useful for rendering the template.
 type Event struct {
 	ID          int64
+	GuildID     string
 	ChannelID   string
 	MessageID   string
 	Emoji       string
 	Date        *time.Time
+	EndDate     *time.Time
 	Title       string
 	Location    string
 	Price       string
 	Description string
 	AuthorID    string
+	Capacity    int // 0 means unlimited
+	MaxGuests   int // -1 means plus-ones are not capped
+	Deadline    *time.Time
+	// ReactionRSVP enables RSVPs via reactions on the event message.
+	ReactionRSVP bool
+	Status       string // "active" or "cancelled"
+	// Public announcement card with a Join button, if one was posted.
+	AnnounceChannelID string
+	AnnounceMessageID string
+	JoinAsMaybe       bool // RSVP "maybe" for people who join via the card
+	// Visibility is "public", "private" or "roles"; VisibilityRoles lists the
+	// roles that can see a role-restricted event.
+	Visibility      string
+	VisibilityRoles []string
+	Sequence        int // iCalendar SEQUENCE
+	UpdatedAt       time.Time
+	// Discord scheduled event mirroring this one, if any.
+	ScheduledEventID string
+	// Where the event lives on the CalDAV collection and its last known ETag.
+	CalDAVHref string
+	CalDAVETag string
 }
 
-// GetEventByChannel fetches an event by channel_id.
-func GetEventByChannel(channelID string) (*Event, error) {
-	if db == nil {
-		return nil, fmt.Errorf("db not initialized")
+// Cancelled reports whether the event has been called off.
+func (e *Event) Cancelled() bool {
+	return e.Status == "cancelled"
+}
+
+// End returns when the event finishes: the explicit end if one was set,
+// otherwise the start. Anything that cares whether an event is over should
+// use this rather than Date. It returns nil when the event has no date.
+func (e *Event) End() *time.Time {
+	if e.EndDate != nil {
+		return e.EndDate
 	}
-	q := `SELECT id, discord_channel_id, discord_message_id, emoji, date, title, location, price, description, author_id FROM events WHERE discord_channel_id = $1 LIMIT 1`
+	return e.Date
+}
+
+// RSVPClosed reports whether the event's RSVP deadline has passed.
+func (e *Event) RSVPClosed() bool {
+	return e.Deadline != nil && time.Now().After(*e.Deadline)
+}
+
+// eventColumns lists the columns scanEvent expects, in order.
+const eventColumns = `id, discord_channel_id, discord_message_id, emoji, date, end_date, title, location, price, description, author_id, COALESCE(capacity, 0), COALESCE(max_guests, -1), rsvp_deadline, reaction_rsvp, status, announce_channel_id, announce_message_id, join_as_maybe, visibility, visibility_roles, sequence, COALESCE(updated_at, CURRENT_TIMESTAMP), guild_id, scheduled_event_id, caldav_href, caldav_etag`
+
+// scanEvent reads a row selected with eventColumns.
+func scanEvent(row interface{ Scan(...interface{}) error }) (*Event, error) {
 	var e Event
-	var nt sql.NullTime
-	err := db.QueryRow(q, channelID).Scan(&e.ID, &e.ChannelID, &e.MessageID, &e.Emoji, &nt, &e.Title, &e.Location, &e.Price, &e.Description, &e.AuthorID)
+	var nt, ne, nd sql.NullTime
+	var roles string
+	err := row.Scan(&e.ID, &e.ChannelID, &e.MessageID, &e.Emoji, &nt, &ne, &e.Title, &e.Location, &e.Price, &e.Description, &e.AuthorID, &e.Capacity, &e.MaxGuests, &nd, &e.ReactionRSVP, &e.Status, &e.AnnounceChannelID, &e.AnnounceMessageID, &e.JoinAsMaybe, &e.Visibility, &roles, &e.Sequence, &e.UpdatedAt, &e.GuildID, &e.ScheduledEventID, &e.CalDAVHref, &e.CalDAVETag)
 	if err != nil {
 		return nil, err
 	}
 	if nt.Valid {
 		e.Date = &nt.Time
 	}
+	if ne.Valid {
+		e.EndDate = &ne.Time
+	}
+	if nd.Valid {
+		e.Deadline = &nd.Time
+	}
+	e.VisibilityRoles = strings.Fields(roles)
 	return &e, nil
 }
 
-// UpsertResponse inserts or updates a user's response for an event.
-func UpsertResponse(eventID int64, userID, responseType string) error {
+// GetEventByChannel fetches an event by channel_id.
+func GetEventByChannel(channelID string) (*Event, error) {
 	if db == nil {
-		return fmt.Errorf("db not initialized")
+		return nil, fmt.Errorf("db not initialized")
 	}
-	// normalize and validate responseType
-	resp := strings.ToLower(strings.TrimSpace(responseType))
-	allowed := map[string]bool{"yes": true, "maybe": true, "no": true}
-	if !allowed[resp] {
-		return fmt.Errorf("invalid response type: %s", responseType)
+	q := `SELECT ` + eventColumns + ` FROM events WHERE discord_channel_id = $1 LIMIT 1`
+	return scanEvent(db.QueryRow(q, channelID))
+}
+
+// GetEventByID fetches an event by its id.
+func GetEventByID(eventID int64) (*Event, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
 	}
-	var existingID int64
-	err := db.QueryRow("SELECT id FROM event_responses WHERE event_id = $1 AND user_id = $2", eventID, userID).Scan(&existingID)
-	if err == sql.ErrNoRows {
-		_, err := db.Exec("INSERT INTO event_responses (event_id, user_id, response_type) VALUES ($1,$2,$3)", eventID, userID, resp)
-		return err
+	q := `SELECT ` + eventColumns + ` FROM events WHERE id = $1`
+	return scanEvent(db.QueryRow(q, eventID))
+}
+
+// GetEventByMessage fetches an event by the id of its posted message.
+func GetEventByMessage(messageID string) (*Event, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
+	}
+	q := `SELECT ` + eventColumns + ` FROM events WHERE discord_message_id = $1 LIMIT 1`
+	return scanEvent(db.QueryRow(q, messageID))
+}
+
+// ListReactionRSVPEvents returns events that take RSVPs via reactions and
+// haven't ended yet.
+func ListReactionRSVPEvents() ([]*Event, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
 	}
+	q := `SELECT ` + eventColumns + ` FROM events
+        WHERE reaction_rsvp AND discord_message_id <> '' AND COALESCE(end_date, date, CURRENT_TIMESTAMP) >= CURRENT_TIMESTAMP`
+	rows, err := db.Query(q)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	_, err = db.Exec("UPDATE event_responses SET response_type = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2", resp, existingID)
-	return err
+	defer rows.Close()
+	var events []*Event
+	for rows.Next() {
+		ev, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	return events, rows.Err()
 }
 
-// GetResponsesForEvent returns lists of user IDs for each response type.
-func GetResponsesForEvent(eventID int64) (going, maybe, cant []string, err error) {
+// RSVPOutcome describes side effects of saving a response.
+type RSVPOutcome struct {
+	Waitlisted bool     // the user said yes but the event is full
+	Promoted   []string // users moved off the waitlist by this change
+	// GuestsCapped is set when the plus-ones were above the event's
+	// current limit and were lowered to Guests.
+	GuestsCapped bool
+	Guests       int
+}
+
+// ErrNoRoomForGuests is returned when a confirmed attendee asks to bring more
+// plus-ones than the seats left. Nothing is changed, so they keep their seat.
+var ErrNoRoomForGuests = errors.New("no room for more guests")
+
+// EventFilter narrows ListEvents. Zero fields don't filter.
+type EventFilter struct {
+	From        time.Time  // still running at or after From
+	To          *time.Time // starting no later than To
+	OrganizerID string     // author or co-host
+	MemberID    string     // organizer, co-host or RSVP'd yes/maybe
+	Tag         string     // #tag in the title or notes; letters, digits, _ and - only
+}
+
+// ListEvents returns the active events matching f, soonest first.
+func ListEvents(f EventFilter) ([]*Event, error) {
 	if db == nil {
-		return nil, nil, nil, fmt.Errorf("db not initialized")
+		return nil, fmt.Errorf("db not initialized")
+	}
+	args := []interface{}{f.From}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	q := `SELECT ` + eventColumns + ` FROM events WHERE status = 'active' AND COALESCE(end_date, date) >= $1`
+	if f.To != nil {
+		q += ` AND date <= ` + arg(*f.To)
 	}
-	rows, err := db.Query("SELECT user_id, response_type FROM event_responses WHERE event_id = $1", eventID)
+	if f.OrganizerID != "" {
+		p := arg(f.OrganizerID)
+		q += ` AND (author_id = ` + p + ` OR EXISTS (SELECT 1 FROM event_cohosts c WHERE c.event_id = events.id AND c.user_id = ` + p + `))`
+	}
+	if f.MemberID != "" {
+		p := arg(f.MemberID)
+		q += ` AND (author_id = ` + p + `
+            OR EXISTS (SELECT 1 FROM event_cohosts c WHERE c.event_id = events.id AND c.user_id = ` + p + `)
+            OR EXISTS (SELECT 1 FROM event_responses r WHERE r.event_id = events.id AND r.user_id = ` + p + ` AND r.response_type IN ('yes', 'maybe')))`
+	}
+	if f.Tag != "" {
+		q += ` AND (title || ' ' || COALESCE(description, '')) ~* ('#' || ` + arg(f.Tag) + ` || '\M')`
+	}
+	q += ` ORDER BY date, id`
+	rows, err := db.Query(q, args...)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, err
 	}
 	defer rows.Close()
+	var events []*Event
 	for rows.Next() {
-		var userID, resp string
-		if err := rows.Scan(&userID, &resp); err != nil {
-			return nil, nil, nil, err
-		}
-		switch resp {
-		case "yes":
-			going = append(going, userID)
-		case "maybe":
-			maybe = append(maybe, userID)
-		case "no":
-			cant = append(cant, userID)
-		default:
-			// ignore unknown
+		ev, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
 		}
+		events = append(events, ev)
 	}
-	return going, maybe, cant, nil
+	return events, rows.Err()
 }
 
-func UpdateEventFieldByChannel(channelID, field, value string) error {
+// GetGoingCount returns the event's confirmed headcount including guests.
+func GetGoingCount(eventID int64) (int, error) {
 	if db == nil {
-		return fmt.Errorf("db not initialized")
+		return 0, fmt.Errorf("db not initialized")
 	}
-	// Only allow certain logical fields and map them to actual column names to avoid SQL injection.
-	fieldMap := map[string]string{
-		"title":       "title",
-		"date":        "date",
-		"location":    "location",
-		"price":       "price",
-		"emoji":       "emoji",
-		"message_id":  "discord_message_id",
-		"description": "description",
+	var n int
+	err := db.QueryRow(`SELECT COALESCE(SUM(1 + guests), 0) FROM event_responses
+        WHERE event_id = $1 AND response_type = 'yes' AND waitlisted_at IS NULL`, eventID).Scan(&n)
+	return n, err
+}
+
+// UserEvent is an event the user RSVP'd yes or maybe to.
+type UserEvent struct {
+	*Event
+	Response   string
+	Waitlisted bool
+}
+
+// ListUserEvents returns the events userID said yes or maybe to, by date.
+func ListUserEvents(userID string) ([]UserEvent, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
 	}
-	col, ok := fieldMap[field]
-	if !ok {
-		return fmt.Errorf("field %s not allowed", field)
+	q := `SELECT ` + eventColumns + `, r.response_type, r.waitlisted_at IS NOT NULL FROM events
+        JOIN (SELECT event_id, response_type, waitlisted_at FROM event_responses
+              WHERE user_id = $1 AND response_type IN ('yes', 'maybe')) r ON r.event_id = events.id
+        ORDER BY date, id`
+	rows, err := db.Query(q, userID)
+	if err != nil {
+		return nil, err
 	}
-	q := fmt.Sprintf("UPDATE events SET %s = $1, updated_at = CURRENT_TIMESTAMP WHERE discord_channel_id = $2", col)
-	_, err := db.Exec(q, value, channelID)
-	return err
+	defer rows.Close()
+	var out []UserEvent
+	for rows.Next() {
+		var ue UserEvent
+		ev, err := scanEvent(withExtraScan{rows, []interface{}{&ue.Response, &ue.Waitlisted}})
+		if err != nil {
+			return nil, err
+		}
+		ue.Event = ev
+		out = append(out, ue)
+	}
+	return out, rows.Err()
+}
+
+// withExtraScan lets scanEvent read rows that select columns after
+// eventColumns; they are scanned into extra.
+type withExtraScan struct {
+	row   interface{ Scan(...interface{}) error }
+	extra []interface{}
+}
+
+func (w withExtraScan) Scan(dest ...interface{}) error {
+	return w.row.Scan(append(dest, w.extra...)...)
+}
+
+// UpsertResponse inserts or updates a user's response for an event. guests is
+// the number of plus-ones the user is bringing; a negative value keeps the
+// current count, lowered to the event's max_guests if that has since dropped.
+// When the event has a capacity, a "yes" whose party doesn't fit is placed on
+// the waitlist, and any change that frees seats promotes waitlisted users in
+// queue order. A confirmed attendee keeps their seat: adding guests that don't
+// fit fails with ErrNoRoomForGuests instead of moving them to the waitlist.
+func UpsertResponse(eventID int64, userID, actorID, responseType string, guests int) (*RSVPOutcome, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
+	}
+	// normalize and validate responseType
+	resp := strings.ToLower(strings.TrimSpace(responseType))
+	allowed := map[string]bool{"yes": true, "maybe": true, "no": true}
+	if !allowed[resp] {
+		return nil, fmt.Errorf("invalid response type: %s", responseType)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the event row so concurrent yeses can't both take the last seat.
+	var capacity, maxGuests sql.NullInt64
+	if err := tx.QueryRow("SELECT capacity, max_guests FROM events WHERE id = $1 FOR UPDATE", eventID).Scan(&capacity, &maxGuests); err != nil {
+		return nil, err
+	}
+
+	var existingID int64
+	var prevResp string
+	var prevGuests int
+	var prevWait sql.NullTime
+	err = tx.QueryRow("SELECT id, response_type, guests, waitlisted_at FROM event_responses WHERE event_id = $1 AND user_id = $2", eventID, userID).Scan(&existingID, &prevResp, &prevGuests, &prevWait)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	exists := err == nil
+	out := &RSVPOutcome{}
+	if guests < 0 {
+		guests = prevGuests
+	}
+	if maxGuests.Valid && int64(guests) > maxGuests.Int64 {
+		guests = int(maxGuests.Int64)
+		out.GuestsCapped = true
+	}
+	if resp == "no" {
+		// nobody brings guests to an event they're skipping
+		guests = 0
+		out.GuestsCapped = false
+	}
+	out.Guests = guests
+
+	var waitArg interface{}
+	if resp == "yes" && capacity.Valid {
+		wasConfirmed := exists && prevResp == "yes" && !prevWait.Valid
+		if exists && prevResp == "yes" && prevWait.Valid {
+			// already queued: keep the position, promoteWaitlist moves them up in turn
+			waitArg = prevWait.Time
+		} else {
+			var taken, waiting int64
+			if err := tx.QueryRow(`SELECT COALESCE(SUM(1 + guests) FILTER (WHERE waitlisted_at IS NULL), 0), COUNT(*) FILTER (WHERE waitlisted_at IS NOT NULL)
+                FROM event_responses WHERE event_id = $1 AND response_type = 'yes' AND user_id <> $2`, eventID, userID).Scan(&taken, &waiting); err != nil {
+				return nil, err
+			}
+			fits := taken+1+int64(guests) <= capacity.Int64
+			switch {
+			case wasConfirmed && guests > prevGuests && !fits:
+				return nil, ErrNoRoomForGuests
+			case wasConfirmed:
+				// a seat once given is kept, even if the capacity has since dropped
+			case !fits || waiting > 0:
+				// new yeses join the back of an existing queue rather than skip it
+				waitArg = time.Now()
+			}
+		}
+	}
+
+	if exists {
+		_, err = tx.Exec("UPDATE event_responses SET response_type = $1, guests = $2, waitlisted_at = $3, updated_at = CURRENT_TIMESTAMP WHERE id = $4", resp, guests, waitArg, existingID)
+	} else {
+		_, err = tx.Exec("INSERT INTO event_responses (event_id, user_id, response_type, guests, waitlisted_at) VALUES ($1,$2,$3,$4,$5)", eventID, userID, resp, guests, waitArg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if actorID == userID {
+		// answering for themselves supersedes any proxy request awaiting them
+		if _, err := tx.Exec("DELETE FROM pending_proxy_rsvps WHERE event_id = $1 AND user_id = $2", eventID, userID); err != nil {
+			return nil, err
+		}
+	}
+	if !exists || prevResp != resp || prevGuests != guests || prevWait.Valid != (waitArg != nil) {
+		c := ResponseChange{UserID: userID, ActorID: actorID, NewResponse: resp, NewGuests: guests, NewWaitlisted: waitArg != nil}
+		if exists {
+			c.OldResponse, c.OldGuests, c.OldWaitlisted = prevResp, prevGuests, prevWait.Valid
+		}
+		if err := recordResponseChange(tx, eventID, c); err != nil {
+			return nil, err
+		}
+	}
+
+	// Fill any seats this change freed (a switch away, or fewer guests).
+	promoted, err := promoteWaitlist(tx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	out.Waitlisted = waitArg != nil
+	for _, id := range promoted {
+		if id == userID {
+			out.Waitlisted = false
+			continue
+		}
+		out.Promoted = append(out.Promoted, id)
+	}
+	return out, tx.Commit()
+}
+
+// recordResponseChange appends c to event_response_history; its ChangedAt
+// is ignored in favour of the current time.
+func recordResponseChange(tx *sql.Tx, eventID int64, c ResponseChange) error {
+	_, err := tx.Exec(`INSERT INTO event_response_history (event_id, user_id, actor_id, old_response, new_response, old_guests, new_guests, old_waitlisted, new_waitlisted)
+        VALUES ($1,$2,$3,NULLIF($4,''),NULLIF($5,''),$6,$7,$8,$9)`, eventID, c.UserID, c.ActorID, c.OldResponse, c.NewResponse, c.OldGuests, c.NewGuests, c.OldWaitlisted, c.NewWaitlisted)
+	return err
+}
+
+// ResponseChange is one row of an event's RSVP history.
+type ResponseChange struct {
+	UserID      string
+	ActorID     string
+	OldResponse string // "" for a first response
+	NewResponse string // "" for a withdrawal
+	OldGuests   int
+	NewGuests   int
+	// OldWaitlisted and NewWaitlisted mark a yes that was on the waitlist.
+	OldWaitlisted bool
+	NewWaitlisted bool
+	ChangedAt     time.Time
+}
+
+// GetResponseHistory returns an event's RSVP changes, oldest first.
+func GetResponseHistory(eventID int64) ([]ResponseChange, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
+	}
+	rows, err := db.Query(`SELECT user_id, actor_id, COALESCE(old_response, ''), COALESCE(new_response, ''), old_guests, new_guests, old_waitlisted, new_waitlisted, changed_at
+        FROM event_response_history WHERE event_id = $1 ORDER BY changed_at, id`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var history []ResponseChange
+	for rows.Next() {
+		var c ResponseChange
+		if err := rows.Scan(&c.UserID, &c.ActorID, &c.OldResponse, &c.NewResponse, &c.OldGuests, &c.NewGuests, &c.OldWaitlisted, &c.NewWaitlisted, &c.ChangedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, c)
+	}
+	return history, rows.Err()
+}
+
+// promoteWaitlist confirms waitlisted users in queue order while their whole
+// party fits, returning the promoted user IDs. It stops at the first party
+// that doesn't fit so nobody skips the queue. Each promotion is recorded in
+// the history. The caller must hold the event row lock.
+func promoteWaitlist(tx *sql.Tx, eventID int64) ([]string, error) {
+	var capacity sql.NullInt64
+	if err := tx.QueryRow("SELECT capacity FROM events WHERE id = $1", eventID).Scan(&capacity); err != nil {
+		return nil, err
+	}
+	var taken int64
+	if err := tx.QueryRow("SELECT COALESCE(SUM(1 + guests), 0) FROM event_responses WHERE event_id = $1 AND response_type = 'yes' AND waitlisted_at IS NULL", eventID).Scan(&taken); err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query("SELECT id, user_id, guests FROM event_responses WHERE event_id = $1 AND response_type = 'yes' AND waitlisted_at IS NOT NULL ORDER BY waitlisted_at", eventID)
+	if err != nil {
+		return nil, err
+	}
+	type queued struct {
+		id     int64
+		userID string
+		party  int64
+	}
+	var queue []queued
+	for rows.Next() {
+		var q queued
+		var guests int64
+		if err := rows.Scan(&q.id, &q.userID, &guests); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		q.party = 1 + guests
+		queue = append(queue, q)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var promoted []string
+	for _, q := range queue {
+		// no capacity means everyone fits
+		if capacity.Valid && taken+q.party > capacity.Int64 {
+			break
+		}
+		if _, err := tx.Exec("UPDATE event_responses SET waitlisted_at = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = $1", q.id); err != nil {
+			return nil, err
+		}
+		guests := int(q.party - 1)
+		promotion := ResponseChange{UserID: q.userID, ActorID: q.userID, OldResponse: "yes", NewResponse: "yes", OldGuests: guests, NewGuests: guests, OldWaitlisted: true}
+		if err := recordResponseChange(tx, eventID, promotion); err != nil {
+			return nil, err
+		}
+		taken += q.party
+		promoted = append(promoted, q.userID)
+	}
+	return promoted, nil
+}
+
+// SetEventCapacity changes an event's attendee limit (0 removes it) and
+// promotes waitlisted users into any seats that opened up.
+func SetEventCapacity(eventID int64, capacity int) ([]string, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	var capArg interface{}
+	if capacity > 0 {
+		capArg = capacity
+	}
+	if _, err := tx.Exec("SELECT id FROM events WHERE id = $1 FOR UPDATE", eventID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("UPDATE events SET capacity = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2", capArg, eventID); err != nil {
+		return nil, err
+	}
+	promoted, err := promoteWaitlist(tx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	return promoted, tx.Commit()
+}
+
+// GetUserResponse returns the user's current response for an event, or ""
+// when they haven't responded.
+func GetUserResponse(eventID int64, userID string) (string, error) {
+	if db == nil {
+		return "", fmt.Errorf("db not initialized")
+	}
+	var resp string
+	err := db.QueryRow("SELECT response_type FROM event_responses WHERE event_id = $1 AND user_id = $2", eventID, userID).Scan(&resp)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return resp, err
+}
+
+// DeleteResponse removes a user's response entirely, records the withdrawal
+// in the history and promotes waitlisted users into any seat it frees.
+func DeleteResponse(eventID int64, userID, actorID string) ([]string, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("SELECT id FROM events WHERE id = $1 FOR UPDATE", eventID); err != nil {
+		return nil, err
+	}
+	var prevResp string
+	var prevGuests int
+	var prevWaitlisted bool
+	err = tx.QueryRow("DELETE FROM event_responses WHERE event_id = $1 AND user_id = $2 RETURNING response_type, guests, waitlisted_at IS NOT NULL", eventID, userID).Scan(&prevResp, &prevGuests, &prevWaitlisted)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	withdrawal := ResponseChange{UserID: userID, ActorID: actorID, OldResponse: prevResp, OldGuests: prevGuests, OldWaitlisted: prevWaitlisted}
+	if err := recordResponseChange(tx, eventID, withdrawal); err != nil {
+		return nil, err
+	}
+	promoted, err := promoteWaitlist(tx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	return promoted, tx.Commit()
+}
+
+// GetResponsesForEvent returns lists of user IDs for each response type.
+func GetResponsesForEvent(eventID int64) (going, maybe, cant []string, err error) {
+	if db == nil {
+		return nil, nil, nil, fmt.Errorf("db not initialized")
+	}
+	// waitlisted yeses are reported separately by GetWaitlistForEvent
+	rows, err := db.Query("SELECT user_id, response_type FROM event_responses WHERE event_id = $1 AND waitlisted_at IS NULL", eventID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userID, resp string
+		if err := rows.Scan(&userID, &resp); err != nil {
+			return nil, nil, nil, err
+		}
+		switch resp {
+		case "yes":
+			going = append(going, userID)
+		case "maybe":
+			maybe = append(maybe, userID)
+		case "no":
+			cant = append(cant, userID)
+		default:
+			// ignore unknown
+		}
+	}
+	return going, maybe, cant, nil
+}
+
+// ResponseDetail holds the optional extras on a user's response.
+type ResponseDetail struct {
+	Guests  int
+	Comment string
+}
+
+// GetResponseDetails returns plus-ones and comments for each user on an event
+// that has either.
+func GetResponseDetails(eventID int64) (map[string]ResponseDetail, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
+	}
+	rows, err := db.Query("SELECT user_id, guests, comment FROM event_responses WHERE event_id = $1 AND (guests > 0 OR comment <> '')", eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	details := map[string]ResponseDetail{}
+	for rows.Next() {
+		var userID string
+		var d ResponseDetail
+		if err := rows.Scan(&userID, &d.Guests, &d.Comment); err != nil {
+			return nil, err
+		}
+		details[userID] = d
+	}
+	return details, rows.Err()
+}
+
+// SetResponseComment sets or clears (empty comment) the note on a user's
+// existing response. It returns false when the user hasn't responded yet.
+func SetResponseComment(eventID int64, userID, comment string) (bool, error) {
+	if db == nil {
+		return false, fmt.Errorf("db not initialized")
+	}
+	res, err := db.Exec("UPDATE event_responses SET comment = $1, updated_at = CURRENT_TIMESTAMP WHERE event_id = $2 AND user_id = $3", comment, eventID, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetResponseComment returns the note on a user's response, if any.
+func GetResponseComment(eventID int64, userID string) (string, error) {
+	if db == nil {
+		return "", fmt.Errorf("db not initialized")
+	}
+	var comment string
+	err := db.QueryRow("SELECT comment FROM event_responses WHERE event_id = $1 AND user_id = $2", eventID, userID).Scan(&comment)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return comment, err
+}
+
+// GetWaitlistForEvent returns waitlisted user IDs in queue order.
+func GetWaitlistForEvent(eventID int64) ([]string, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
+	}
+	rows, err := db.Query("SELECT user_id FROM event_responses WHERE event_id = $1 AND response_type = 'yes' AND waitlisted_at IS NOT NULL ORDER BY waitlisted_at", eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		ids = append(ids, userID)
+	}
+	return ids, rows.Err()
+}
+
+func UpdateEventFieldByChannel(channelID, field, value string) error {
+	if db == nil {
+		return fmt.Errorf("db not initialized")
+	}
+	// Only allow certain logical fields and map them to actual column names to avoid SQL injection.
+	fieldMap := map[string]string{
+		"title":       "title",
+		"date":        "date",
+		"location":    "location",
+		"price":       "price",
+		"emoji":       "emoji",
+		"message_id":  "discord_message_id",
+		"description": "description",
+		"max_guests":  "max_guests",
+		"deadline":    "rsvp_deadline",
+		"reactions":   "reaction_rsvp",
+		"status":      "status",
+		// announcement card
+		"announce_channel_id": "announce_channel_id",
+		"announce_message_id": "announce_message_id",
+		"join_as_maybe":       "join_as_maybe",
+		"visibility":          "visibility",
+		"visibility_roles":    "visibility_roles",
+		"scheduled_event_id":  "scheduled_event_id",
+	}
+	col, ok := fieldMap[field]
+	if !ok {
+		return fmt.Errorf("field %s not allowed", field)
+	}
+	// Nullable columns are cleared by passing an empty value.
+	nullable := map[string]bool{"max_guests": true, "rsvp_deadline": true}
+	var arg interface{} = value
+	if nullable[col] && value == "" {
+		arg = nil
+	}
+	// Calendar-visible changes bump the iCalendar SEQUENCE.
+	sequenced := map[string]bool{"date": true, "location": true, "status": true}
+	bump := ""
+	if sequenced[col] {
+		bump = ", sequence = sequence + 1"
+	}
+	q := fmt.Sprintf("UPDATE events SET %s = $1%s, updated_at = CURRENT_TIMESTAMP WHERE discord_channel_id = $2", col, bump)
+	_, err := db.Exec(q, arg, channelID)
+	return err
+}
+
+// UpdateEventDatesByChannel moves an event to start and end (nil for no end)
+// in one statement, so a move counts as a single calendar change.
+func UpdateEventDatesByChannel(channelID string, start time.Time, end *time.Time) error {
+	if db == nil {
+		return fmt.Errorf("db not initialized")
+	}
+	var endArg interface{}
+	if end != nil {
+		endArg = *end
+	}
+	_, err := db.Exec(`UPDATE events SET date = $1, end_date = $2, sequence = sequence + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE discord_channel_id = $3`, start, endArg, channelID)
+	return err
+}
+
+// AddCohost makes userID a co-host of the event. Adding an existing co-host
+// is a no-op.
+func AddCohost(eventID int64, userID, addedBy string) error {
+	if db == nil {
+		return fmt.Errorf("db not initialized")
+	}
+	_ = upsertUser(userID, "")
+	_, err := db.Exec("INSERT INTO event_cohosts (event_id, user_id, added_by) VALUES ($1,$2,$3) ON CONFLICT DO NOTHING", eventID, userID, addedBy)
+	return err
+}
+
+// RemoveCohost removes userID from the event's co-hosts. It returns false when
+// they weren't one.
+func RemoveCohost(eventID int64, userID string) (bool, error) {
+	if db == nil {
+		return false, fmt.Errorf("db not initialized")
+	}
+	res, err := db.Exec("DELETE FROM event_cohosts WHERE event_id = $1 AND user_id = $2", eventID, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetCohosts returns the event's co-host user IDs in the order they were added.
+func GetCohosts(eventID int64) ([]string, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
+	}
+	rows, err := db.Query("SELECT user_id FROM event_cohosts WHERE event_id = $1 ORDER BY created_at", eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// IsCohost reports whether userID co-hosts the event.
+func IsCohost(eventID int64, userID string) (bool, error) {
+	if db == nil {
+		return false, fmt.Errorf("db not initialized")
+	}
+	var exists bool
+	err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM event_cohosts WHERE event_id = $1 AND user_id = $2)", eventID, userID).Scan(&exists)
+	return exists, err
+}
+
+// EventInvite is a user or role on an event's invite roster.
+type EventInvite struct {
+	TargetID   string
+	TargetType string // "user" or "role"
+}
+
+// AddInvite records an invite. Re-inviting is a no-op.
+func AddInvite(eventID int64, targetID, targetType, invitedBy string) error {
+	if db == nil {
+		return fmt.Errorf("db not initialized")
+	}
+	if targetType == "user" {
+		_ = upsertUser(targetID, "")
+	}
+	_, err := db.Exec("INSERT INTO event_invites (event_id, target_id, target_type, invited_by) VALUES ($1,$2,$3,$4) ON CONFLICT DO NOTHING", eventID, targetID, targetType, invitedBy)
+	return err
+}
+
+// RemoveInvite drops targetID from the roster. It returns false when they
+// weren't on it.
+func RemoveInvite(eventID int64, targetID string) (bool, error) {
+	if db == nil {
+		return false, fmt.Errorf("db not initialized")
+	}
+	res, err := db.Exec("DELETE FROM event_invites WHERE event_id = $1 AND target_id = $2", eventID, targetID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetInvites returns the event's invite roster in the order invites were sent.
+func GetInvites(eventID int64) ([]EventInvite, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
+	}
+	rows, err := db.Query("SELECT target_id, target_type FROM event_invites WHERE event_id = $1 ORDER BY created_at", eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var invites []EventInvite
+	for rows.Next() {
+		var inv EventInvite
+		if err := rows.Scan(&inv.TargetID, &inv.TargetType); err != nil {
+			return nil, err
+		}
+		invites = append(invites, inv)
+	}
+	return invites, rows.Err()
+}
+
+// GuildSettings holds per-guild configuration.
+type GuildSettings struct {
+	GuildID string
+	// OpenProxyRSVP lets anyone RSVP for others, pending the target's
+	// confirmation. When false only organizers may RSVP for others.
+	OpenProxyRSVP bool
+	// AnnouncementsChannelID is where public cards for events are posted.
+	AnnouncementsChannelID string
+}
+
+// GetGuildSettings returns the guild's settings, or the defaults when none
+// have been saved.
+func GetGuildSettings(guildID string) (*GuildSettings, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
+	}
+	gs := &GuildSettings{GuildID: guildID}
+	err := db.QueryRow("SELECT open_proxy_rsvp, announcements_channel_id FROM guild_settings WHERE guild_id = $1", guildID).Scan(&gs.OpenProxyRSVP, &gs.AnnouncementsChannelID)
+	if err == sql.ErrNoRows {
+		return gs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return gs, nil
+}
+
+// UpdateGuildSetting sets one guild setting, creating the row if needed.
+func UpdateGuildSetting(guildID, field, value string) error {
+	if db == nil {
+		return fmt.Errorf("db not initialized")
+	}
+	// Only allow known settings and map them to column names to avoid SQL injection.
+	fieldMap := map[string]string{
+		"open_proxy_rsvp":       "open_proxy_rsvp",
+		"announcements_channel": "announcements_channel_id",
+	}
+	col, ok := fieldMap[field]
+	if !ok {
+		return fmt.Errorf("setting %s not allowed", field)
+	}
+	q := fmt.Sprintf(`INSERT INTO guild_settings (guild_id, %[1]s) VALUES ($1,$2)
+        ON CONFLICT (guild_id) DO UPDATE SET %[1]s = EXCLUDED.%[1]s, updated_at = CURRENT_TIMESTAMP`, col)
+	_, err := db.Exec(q, guildID, value)
+	return err
+}
+
+// PendingProxyRSVP is a proxy RSVP awaiting the target user's confirmation.
+type PendingProxyRSVP struct {
+	ID       int64
+	EventID  int64
+	UserID   string
+	ActorID  string
+	Response string
+	Guests   int // negative keeps the user's current count
+}
+
+// CreatePendingProxyRSVP stores (or replaces) a proxy request for userID and
+// returns its id.
+func CreatePendingProxyRSVP(eventID int64, userID, actorID, response string, guests int) (int64, error) {
+	if db == nil {
+		return 0, fmt.Errorf("db not initialized")
+	}
+	var id int64
+	err := db.QueryRow(`INSERT INTO pending_proxy_rsvps (event_id, user_id, actor_id, response_type, guests) VALUES ($1,$2,$3,$4,$5)
+        ON CONFLICT (event_id, user_id) DO UPDATE SET actor_id = EXCLUDED.actor_id, response_type = EXCLUDED.response_type, guests = EXCLUDED.guests, created_at = CURRENT_TIMESTAMP
+        RETURNING id`, eventID, userID, actorID, response, guests).Scan(&id)
+	return id, err
+}
+
+// GetPendingProxyRSVP fetches a pending proxy request by id.
+func GetPendingProxyRSVP(id int64) (*PendingProxyRSVP, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
+	}
+	var p PendingProxyRSVP
+	err := db.QueryRow("SELECT id, event_id, user_id, actor_id, response_type, guests FROM pending_proxy_rsvps WHERE id = $1", id).Scan(&p.ID, &p.EventID, &p.UserID, &p.ActorID, &p.Response, &p.Guests)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetPendingProxyRSVPsForEvent returns an event's unconfirmed proxy requests.
+func GetPendingProxyRSVPsForEvent(eventID int64) ([]PendingProxyRSVP, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
+	}
+	rows, err := db.Query("SELECT id, event_id, user_id, actor_id, response_type, guests FROM pending_proxy_rsvps WHERE event_id = $1 ORDER BY created_at", eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var pending []PendingProxyRSVP
+	for rows.Next() {
+		var p PendingProxyRSVP
+		if err := rows.Scan(&p.ID, &p.EventID, &p.UserID, &p.ActorID, &p.Response, &p.Guests); err != nil {
+			return nil, err
+		}
+		pending = append(pending, p)
+	}
+	return pending, rows.Err()
+}
+
+// DeletePendingProxyRSVP removes a pending proxy request.
+func DeletePendingProxyRSVP(id int64) error {
+	if db == nil {
+		return fmt.Errorf("db not initialized")
+	}
+	_, err := db.Exec("DELETE FROM pending_proxy_rsvps WHERE id = $1", id)
+	return err
+}
+
+// GetEventByScheduledEvent looks up the event mirrored by a Discord
+// scheduled event.
+func GetEventByScheduledEvent(scheduledEventID string) (*Event, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
+	}
+	q := `SELECT ` + eventColumns + ` FROM events WHERE scheduled_event_id = $1 LIMIT 1`
+	return scanEvent(db.QueryRow(q, scheduledEventID))
+}
+
+// ListScheduledEventMirrors returns active events that have a scheduled
+// event mirror.
+func ListScheduledEventMirrors() ([]*Event, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
+	}
+	q := `SELECT ` + eventColumns + ` FROM events WHERE scheduled_event_id <> '' AND status = 'active'`
+	rows, err := db.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []*Event
+	for rows.Next() {
+		ev, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
+
+// SetEventCalDAV records where the event was pushed on the CalDAV
+// collection. It leaves updated_at alone since the event itself didn't change.
+func SetEventCalDAV(eventID int64, href, etag string) error {
+	if db == nil {
+		return fmt.Errorf("db not initialized")
+	}
+	_, err := db.Exec("UPDATE events SET caldav_href = $1, caldav_etag = $2 WHERE id = $3", href, etag, eventID)
+	return err
+}
+
+// calendarFeedHistory is how far back the calendar feeds reach, so recently
+// finished or cancelled events don't vanish from calendars straight away.
+const calendarFeedHistory = 30 * 24 * time.Hour
+
+// ListPublicFeedEvents returns the guild's public events for its calendar
+// feed, cancelled ones included so subscribers see the cancellation.
+func ListPublicFeedEvents(guildID string) ([]*Event, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
+	}
+	q := `SELECT ` + eventColumns + ` FROM events
+        WHERE guild_id = $1 AND visibility = 'public' AND date IS NOT NULL AND COALESCE(end_date, date) >= $2
+        ORDER BY date, id`
+	rows, err := db.Query(q, guildID, time.Now().Add(-calendarFeedHistory))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []*Event
+	for rows.Next() {
+		ev, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
+
+// ClaimUnassignedEvents assigns events created before events recorded their
+// guild to guildID, the guild the bot runs in.
+func ClaimUnassignedEvents(guildID string) error {
+	if db == nil {
+		return fmt.Errorf("db not initialized")
+	}
+	_, err := db.Exec(`UPDATE events SET guild_id = $1 WHERE guild_id = ''`, guildID)
+	return err
+}
+
+// GetCalendarToken returns the user's calendar feed token, creating one the
+// first time or replacing it when reset is set.
+func GetCalendarToken(userID string, reset bool) (string, error) {
+	if db == nil {
+		return "", fmt.Errorf("db not initialized")
+	}
+	if !reset {
+		var token string
+		err := db.QueryRow("SELECT token FROM calendar_tokens WHERE user_id = $1", userID).Scan(&token)
+		if err == nil {
+			return token, nil
+		}
+		if err != sql.ErrNoRows {
+			return "", err
+		}
+	}
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+	_, err := db.Exec(`INSERT INTO calendar_tokens (user_id, token) VALUES ($1, $2)
+        ON CONFLICT (user_id) DO UPDATE SET token = EXCLUDED.token, created_at = CURRENT_TIMESTAMP`, userID, token)
+	return token, err
+}
+
+// GetCalendarTokenUser returns the user a calendar feed token belongs to.
+func GetCalendarTokenUser(token string) (string, error) {
+	if db == nil {
+		return "", fmt.Errorf("db not initialized")
+	}
+	var userID string
+	err := db.QueryRow("SELECT user_id FROM calendar_tokens WHERE token = $1", token).Scan(&userID)
+	return userID, err
+}
+
+// DatePoll asks an event's members which candidate times work for them.
+type DatePoll struct {
+	ID        int64
+	EventID   int64
+	MessageID string
+	CreatedBy string
+	Chosen    int // position of the finalized option, or -1 while open
+	Options   []DatePollOption
+}
+
+// DatePollOption is one candidate time, numbered from 1.
+type DatePollOption struct {
+	Position int
+	Start    time.Time
+	End      *time.Time
+}
+
+// Open reports whether the poll is still taking votes.
+func (p *DatePoll) Open() bool {
+	return p.Chosen < 0
+}
+
+// CreateDatePoll stores a poll and its options and returns its id.
+func CreateDatePoll(eventID int64, createdBy string, options []DatePollOption) (int64, error) {
+	if db == nil {
+		return 0, fmt.Errorf("db not initialized")
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	var id int64
+	if err := tx.QueryRow("INSERT INTO date_polls (event_id, created_by) VALUES ($1,$2) RETURNING id", eventID, createdBy).Scan(&id); err != nil {
+		return 0, err
+	}
+	for _, o := range options {
+		if _, err := tx.Exec("INSERT INTO date_poll_options (poll_id, position, starts_at, ends_at) VALUES ($1,$2,$3,$4)", id, o.Position, o.Start, o.End); err != nil {
+			return 0, err
+		}
+	}
+	return id, tx.Commit()
+}
+
+// SetDatePollMessage records the message showing the poll.
+func SetDatePollMessage(pollID int64, messageID string) error {
+	if db == nil {
+		return fmt.Errorf("db not initialized")
+	}
+	_, err := db.Exec("UPDATE date_polls SET message_id = $1 WHERE id = $2", messageID, pollID)
+	return err
+}
+
+// GetDatePoll loads a poll with its options.
+func GetDatePoll(pollID int64) (*DatePoll, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
+	}
+	p := &DatePoll{ID: pollID}
+	var chosen sql.NullInt64
+	err := db.QueryRow("SELECT event_id, message_id, created_by, chosen FROM date_polls WHERE id = $1", pollID).Scan(&p.EventID, &p.MessageID, &p.CreatedBy, &chosen)
+	if err != nil {
+		return nil, err
+	}
+	p.Chosen = -1
+	if chosen.Valid {
+		p.Chosen = int(chosen.Int64)
+	}
+	rows, err := db.Query("SELECT position, starts_at, ends_at FROM date_poll_options WHERE poll_id = $1 ORDER BY position", pollID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var o DatePollOption
+		var end sql.NullTime
+		if err := rows.Scan(&o.Position, &o.Start, &end); err != nil {
+			return nil, err
+		}
+		if end.Valid {
+			o.End = &end.Time
+		}
+		p.Options = append(p.Options, o)
+	}
+	return p, rows.Err()
+}
+
+// GetOpenDatePoll returns the event's most recent poll still taking votes.
+func GetOpenDatePoll(eventID int64) (*DatePoll, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
+	}
+	var id int64
+	err := db.QueryRow("SELECT id FROM date_polls WHERE event_id = $1 AND chosen IS NULL ORDER BY id DESC LIMIT 1", eventID).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return GetDatePoll(id)
+}
+
+// ToggleDatePollVote records a user's vote on one option. Casting the same
+// vote again withdraws it.
+func ToggleDatePollVote(pollID int64, position int, userID, vote string) error {
+	if db == nil {
+		return fmt.Errorf("db not initialized")
+	}
+	res, err := db.Exec("DELETE FROM date_poll_votes WHERE poll_id = $1 AND position = $2 AND user_id = $3 AND vote = $4", pollID, position, userID, vote)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+	_ = upsertUser(userID, "")
+	_, err = db.Exec(`INSERT INTO date_poll_votes (poll_id, position, user_id, vote) VALUES ($1,$2,$3,$4)
+        ON CONFLICT (poll_id, position, user_id) DO UPDATE SET vote = EXCLUDED.vote, updated_at = CURRENT_TIMESTAMP`, pollID, position, userID, vote)
+	return err
+}
+
+// GetDatePollVotes returns each voter's votes by option position, with the
+// voters ordered by their oldest standing vote.
+func GetDatePollVotes(pollID int64) (voters []string, votes map[string]map[int]string, err error) {
+	if db == nil {
+		return nil, nil, fmt.Errorf("db not initialized")
+	}
+	rows, err := db.Query("SELECT user_id, position, vote FROM date_poll_votes WHERE poll_id = $1 ORDER BY updated_at", pollID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	votes = map[string]map[int]string{}
+	for rows.Next() {
+		var userID, vote string
+		var position int
+		if err := rows.Scan(&userID, &position, &vote); err != nil {
+			return nil, nil, err
+		}
+		if votes[userID] == nil {
+			votes[userID] = map[int]string{}
+			voters = append(voters, userID)
+		}
+		votes[userID][position] = vote
+	}
+	return voters, votes, rows.Err()
+}
+
+// ErrDatePollClosed is returned when finalizing a poll someone else has
+// already finalized.
+var ErrDatePollClosed = errors.New("date poll already finalized")
+
+// FinalizeDatePoll closes the poll on the chosen option. Only one caller can
+// close a poll; the rest get ErrDatePollClosed.
+func FinalizeDatePoll(pollID int64, position int) error {
+	if db == nil {
+		return fmt.Errorf("db not initialized")
+	}
+	res, err := db.Exec("UPDATE date_polls SET chosen = $1 WHERE id = $2 AND chosen IS NULL", position, pollID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err == nil && n == 0 {
+		err = ErrDatePollClosed
+	}
+	return err
+}
+
+// EventPreset holds defaults for /event. Empty fields leave the usual
+// default alone. Roles and invitees are kept as the mentions that were typed.
+type EventPreset struct {
+	GuildID    string
+	UserID     string
+	Name       string
+	Title      string // may contain {date}, {weekday} and {time}
+	Location   string
+	Price      string
+	Emoji      string
+	Notes      string
+	Visibility string
+	Roles      string
+	Invitees   string
+}
+
+const presetColumns = "guild_id, user_id, name, title, location, price, emoji, notes, visibility, visibility_roles, invitees"
+
+func scanPreset(row interface{ Scan(...interface{}) error }) (*EventPreset, error) {
+	p := &EventPreset{}
+	if err := row.Scan(&p.GuildID, &p.UserID, &p.Name, &p.Title, &p.Location, &p.Price, &p.Emoji, &p.Notes, &p.Visibility, &p.Roles, &p.Invitees); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// SavePreset stores a preset, replacing one of the same name.
+func SavePreset(p *EventPreset) error {
+	if db == nil {
+		return fmt.Errorf("db not initialized")
+	}
+	_, err := db.Exec(`INSERT INTO event_presets (`+presetColumns+`) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+        ON CONFLICT (guild_id, user_id, name) DO UPDATE SET title = EXCLUDED.title, location = EXCLUDED.location,
+        price = EXCLUDED.price, emoji = EXCLUDED.emoji, notes = EXCLUDED.notes, visibility = EXCLUDED.visibility,
+        visibility_roles = EXCLUDED.visibility_roles, invitees = EXCLUDED.invitees, updated_at = CURRENT_TIMESTAMP`,
+		p.GuildID, p.UserID, p.Name, p.Title, p.Location, p.Price, p.Emoji, p.Notes, p.Visibility, p.Roles, p.Invitees)
+	return err
+}
+
+// GetPreset returns the user's preset with the given name.
+func GetPreset(guildID, userID, name string) (*EventPreset, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
+	}
+	row := db.QueryRow("SELECT "+presetColumns+" FROM event_presets WHERE guild_id = $1 AND user_id = $2 AND name = $3", guildID, userID, name)
+	return scanPreset(row)
+}
+
+// ListPresets returns the user's presets in the guild by name.
+func ListPresets(guildID, userID string) ([]*EventPreset, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
+	}
+	rows, err := db.Query("SELECT "+presetColumns+" FROM event_presets WHERE guild_id = $1 AND user_id = $2 ORDER BY name", guildID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*EventPreset
+	for rows.Next() {
+		p, err := scanPreset(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// DeletePreset removes a preset and reports whether it existed.
+func DeletePreset(guildID, userID, name string) (bool, error) {
+	if db == nil {
+		return false, fmt.Errorf("db not initialized")
+	}
+	res, err := db.Exec("DELETE FROM event_presets WHERE guild_id = $1 AND user_id = $2 AND name = $3", guildID, userID, name)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
 }
 
 // InsertCommand logs a slash command or modal submission for auditing.
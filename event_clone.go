@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func registerEventClone(s *discordgo.Session, guildID string) {
+	cmd := &discordgo.ApplicationCommand{
+		Name:        "event_clone",
+		Description: "Create a new event copying the one in the current channel",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "new_time",
+				Description: "Time/date of the new event; without an end it lasts as long as this one",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "invitees",
+				Description: "Invite the same users and roles",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "cohosts",
+				Description: "Keep the same organizers as co-hosts",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "invite_going",
+				Description: "Invite everyone who said yes to this event",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "confirm",
+				Description: "Preview the event message and confirm before the channel is created",
+				Required:    false,
+			},
+		},
+	}
+	_, err := s.ApplicationCommandCreate(s.State.User.ID, guildID, cmd)
+	if err != nil {
+		log.Printf("Cannot create '/event_clone' command: %v", err)
+	}
+}
+
+func handleEventCloneCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	if i.ApplicationCommandData().Name != "event_clone" {
+		return
+	}
+	ev := requireEventManager(s, i)
+	if ev == nil {
+		return
+	}
+	var timeStr string
+	var withInvitees, withCohosts, inviteGoing, confirm bool
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "new_time":
+			timeStr = opt.StringValue()
+		case "invitees":
+			withInvitees = opt.BoolValue()
+		case "cohosts":
+			withCohosts = opt.BoolValue()
+		case "invite_going":
+			inviteGoing = opt.BoolValue()
+		case "confirm":
+			confirm = opt.BoolValue()
+		}
+	}
+	reply := func(msg string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: msg, Flags: discordgo.MessageFlagsEphemeral},
+		})
+	}
+	start, end, err := ParseFlexibleRange(timeStr)
+	if err != nil {
+		reply("Please provide a valid time (formats like YYYY-MM-DD HH:MM:SS).")
+		return
+	}
+	spec, err := cloneEventSpec(ev, i.Member.User.ID, i.GuildID, start, end, withInvitees, withCohosts, inviteGoing)
+	if err != nil {
+		log.Printf("Failed to load event to clone: %v", err)
+		reply("Failed to copy the event.")
+		return
+	}
+	if confirm {
+		confirmEventCreation(s, i, spec)
+		return
+	}
+	channelName, notice, err := createEventChannel(s, i.GuildID, i.Member.User.ID, spec)
+	if err != nil {
+		log.Printf("Failed to create cloned event: %v", err)
+		reply("Failed to create event channel.")
+		return
+	}
+	msg := fmt.Sprintf("Event channel '%s' created from %s!", channelName, ev.Title)
+	if notice != "" {
+		msg += " " + notice
+	}
+	reply(msg)
+}
+
+// cloneEventSpec copies ev's details to a new event at start, organized by
+// authorID. Without an explicit end the new event runs as long as the old
+// one, and an RSVP deadline keeps the same lead time.
+func cloneEventSpec(ev *Event, authorID, guildID string, start time.Time, end *time.Time, withInvitees, withCohosts, inviteGoing bool) (*eventSpec, error) {
+	spec := &eventSpec{
+		Name:         ev.Title,
+		Location:     ev.Location,
+		Price:        ev.Price,
+		Emoji:        ev.Emoji,
+		Notes:        ev.Description,
+		Start:        start,
+		End:          end,
+		Capacity:     ev.Capacity,
+		MaxGuests:    ev.MaxGuests,
+		Visibility:   ev.Visibility,
+		VisibleRoles: ev.VisibilityRoles,
+	}
+	if end == nil && ev.Date != nil && ev.EndDate != nil {
+		e := start.Add(ev.EndDate.Sub(*ev.Date))
+		spec.End = &e
+	}
+	if ev.Date != nil && ev.Deadline != nil {
+		spec.Deadline = start.Add(ev.Deadline.Sub(*ev.Date))
+	}
+
+	seen := map[string]bool{authorID: true, guildID: true}
+	invite := func(inv EventInvite) {
+		if !seen[inv.TargetID] {
+			seen[inv.TargetID] = true
+			spec.Invitees = append(spec.Invitees, inv)
+		}
+	}
+	if withCohosts {
+		cohosts, err := GetCohosts(ev.ID)
+		if err != nil {
+			return nil, err
+		}
+		// whoever organized the original stays an organizer of the copy
+		for _, id := range append([]string{ev.AuthorID}, cohosts...) {
+			if !seen[id] {
+				seen[id] = true
+				spec.Cohosts = append(spec.Cohosts, id)
+			}
+		}
+	}
+	if withInvitees {
+		invites, err := GetInvites(ev.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, inv := range invites {
+			invite(inv)
+		}
+	}
+	if inviteGoing {
+		going, _, _, err := GetResponsesForEvent(ev.ID)
+		if err != nil {
+			return nil, err
+		}
+		waitlist, err := GetWaitlistForEvent(ev.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range append(going, waitlist...) {
+			invite(EventInvite{TargetID: id, TargetType: "user"})
+		}
+	}
+	return spec, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCloneEventSpecTimes(t *testing.T) {
+	date := time.Date(2025, 5, 2, 19, 0, 0, 0, time.UTC)
+	endDate := date.Add(3 * time.Hour)
+	deadline := date.Add(-24 * time.Hour)
+	start := time.Date(2025, 6, 6, 18, 0, 0, 0, time.UTC)
+	explicitEnd := start.Add(time.Hour)
+	tests := []struct {
+		name         string
+		ev           *Event
+		end          *time.Time
+		wantEnd      *time.Time
+		wantDeadline time.Time
+	}{
+		{name: "keeps the length", ev: &Event{Title: "Poker", Date: &date, EndDate: &endDate, MaxGuests: -1}, wantEnd: timePtr(start.Add(3 * time.Hour))},
+		{name: "explicit end wins", ev: &Event{Title: "Poker", Date: &date, EndDate: &endDate, MaxGuests: -1}, end: &explicitEnd, wantEnd: &explicitEnd},
+		{name: "no end", ev: &Event{Title: "Poker", Date: &date, MaxGuests: -1}},
+		{name: "keeps the deadline lead", ev: &Event{Title: "Poker", Date: &date, Deadline: &deadline, MaxGuests: -1}, wantDeadline: start.Add(-24 * time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := cloneEventSpec(tt.ev, "author", "guild", start, tt.end, false, false, false)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if spec.Name != tt.ev.Title || !spec.Start.Equal(start) || spec.MaxGuests != -1 {
+				t.Errorf("got %+v, want a copy of %q starting %v", spec, tt.ev.Title, start)
+			}
+			if (spec.End == nil) != (tt.wantEnd == nil) || (spec.End != nil && !spec.End.Equal(*tt.wantEnd)) {
+				t.Errorf("end = %v, want %v", spec.End, tt.wantEnd)
+			}
+			if !spec.Deadline.Equal(tt.wantDeadline) {
+				t.Errorf("deadline = %v, want %v", spec.Deadline, tt.wantDeadline)
+			}
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time { return &t }
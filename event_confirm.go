@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// With /event confirm:true nothing is created straight away: the organizer
+// gets a private preview of the event message with Create / Edit / Cancel
+// buttons, "eventcreate:<key>", "eventedit:<key>" and "eventcancel:<key>".
+// Edit opens a modal, "eventeditmodal:<key>", for the free-text fields.
+const (
+	eventCreatePrefix    = "eventcreate:"
+	eventEditPrefix      = "eventedit:"
+	eventCancelPrefix    = "eventcancel:"
+	eventEditModalPrefix = "eventeditmodal:"
+)
+
+// pendingEvent is an /event waiting for the organizer to confirm it.
+type pendingEvent struct {
+	GuildID  string
+	AuthorID string
+	Spec     *eventSpec
+	// Problem explains why the last edit wasn't applied.
+	Problem string
+}
+
+var pendingEvents = newPendingStore[*pendingEvent](30 * time.Minute)
+
+// confirmEventCreation replies with the preview instead of creating the event.
+func confirmEventCreation(s *discordgo.Session, i *discordgo.InteractionCreate, spec *eventSpec) {
+	p := &pendingEvent{GuildID: i.GuildID, AuthorID: i.Member.User.ID, Spec: spec}
+	key := pendingEvents.put(p)
+	content, components := pendingEventMessage(key, p)
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: components,
+			Flags:      discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to send event preview: %v", err)
+	}
+}
+
+// pendingEventMessage shows exactly what will be posted, plus the buttons.
+func pendingEventMessage(key string, p *pendingEvent) (string, []discordgo.MessageComponent) {
+	var b strings.Builder
+	b.WriteString("**Preview** — nothing has been created yet. This is what will be posted in the new event channel:\n")
+	if p.Problem != "" {
+		b.WriteString("⚠️ " + p.Problem + "\n")
+	}
+	b.WriteString("\n")
+	preview, err := RenderEventPreview(p.Spec.previewEvent(p.AuthorID), p.Spec.Invitees, p.Spec.Cohosts)
+	if err != nil {
+		log.Printf("Failed to render event preview: %v", err)
+		preview = "(The preview could not be rendered.)"
+	}
+	b.WriteString(preview)
+	return truncateRunes(b.String(), maxMessageLength), []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Create", Style: discordgo.SuccessButton, CustomID: eventCreatePrefix + key},
+			discordgo.Button{Label: "Edit", Style: discordgo.SecondaryButton, CustomID: eventEditPrefix + key},
+			discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: eventCancelPrefix + key},
+		}},
+	}
+}
+
+// handlePendingEventButtons creates, edits or drops a previewed event.
+func handlePendingEventButtons(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionMessageComponent || i.Member == nil {
+		return
+	}
+	customID := i.MessageComponentData().CustomID
+	if key, ok := strings.CutPrefix(customID, eventCancelPrefix); ok {
+		pendingEvents.take(key)
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{Content: "Event discarded; nothing was created.", Components: []discordgo.MessageComponent{}},
+		})
+		return
+	}
+	if key, ok := strings.CutPrefix(customID, eventEditPrefix); ok {
+		p, ok := pendingEvents.get(key)
+		if !ok {
+			pendingEventExpired(s, i)
+			return
+		}
+		if err := s.InteractionRespond(i.Interaction, pendingEventModal(key, p.Spec)); err != nil {
+			log.Printf("Failed to open event edit modal: %v", err)
+		}
+		return
+	}
+	key, ok := strings.CutPrefix(customID, eventCreatePrefix)
+	if !ok {
+		return
+	}
+	p, ok := pendingEvents.take(key)
+	if !ok {
+		pendingEventExpired(s, i)
+		return
+	}
+	createEventFromPreview(s, i, p.GuildID, p.AuthorID, p.Spec)
+}
+
+// pendingEventModal edits the free-text fields, prefilled with the current
+// values. The time shows as an explicit range so it round-trips through the
+// flexible parser.
+func pendingEventModal(key string, spec *eventSpec) *discordgo.InteractionResponse {
+	loc := defaultLocation()
+	when := spec.Start.In(loc).Format("2006-01-02 15:04")
+	if spec.End != nil {
+		when += " to " + spec.End.In(loc).Format("2006-01-02 15:04")
+	}
+	price := spec.Price
+	if price == "" {
+		price = defaultEventPrice
+	}
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: eventEditModalPrefix + key,
+			Title:    "Edit event",
+			Components: []discordgo.MessageComponent{
+				&discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					&discordgo.TextInput{CustomID: "name", Label: "Name", Style: discordgo.TextInputShort, Required: true, MaxLength: 100, Value: spec.Name},
+				}},
+				&discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					&discordgo.TextInput{CustomID: "time", Label: "Time", Style: discordgo.TextInputShort, Required: true, MaxLength: 100, Value: when},
+				}},
+				&discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					&discordgo.TextInput{CustomID: "location", Label: "Location", Style: discordgo.TextInputShort, Required: true, MaxLength: 200, Value: spec.Location},
+				}},
+				&discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					&discordgo.TextInput{CustomID: "price", Label: "Price", Style: discordgo.TextInputShort, Required: false, MaxLength: 100, Value: price},
+				}},
+				&discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					&discordgo.TextInput{CustomID: "notes", Label: "Notes", Style: discordgo.TextInputParagraph, Required: false, MaxLength: 1000, Value: spec.Notes},
+				}},
+			},
+		},
+	}
+}
+
+// handlePendingEventModal applies an edit and refreshes the preview.
+func handlePendingEventModal(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionModalSubmit {
+		return
+	}
+	key, ok := strings.CutPrefix(i.ModalSubmitData().CustomID, eventEditModalPrefix)
+	if !ok {
+		return
+	}
+	p, ok := pendingEvents.get(key)
+	if !ok {
+		pendingEventExpired(s, i)
+		return
+	}
+	values := map[string]string{}
+	for _, row := range i.ModalSubmitData().Components {
+		if ar, ok := row.(*discordgo.ActionsRow); ok {
+			for _, comp := range ar.Components {
+				if ti, ok := comp.(*discordgo.TextInput); ok {
+					values[ti.CustomID] = strings.TrimSpace(ti.Value)
+				}
+			}
+		}
+	}
+	p.Problem = ""
+	p.Spec.Name, p.Spec.Location, p.Spec.Price, p.Spec.Notes = values["name"], values["location"], values["price"], values["notes"]
+	if start, end, err := ParseFlexibleRange(values["time"]); err != nil {
+		p.Problem = fmt.Sprintf("Couldn't read the time %q, so it wasn't changed.", values["time"])
+	} else {
+		p.Spec.Start, p.Spec.End = start, end
+	}
+	pendingEvents.set(key, p)
+	content, components := pendingEventMessage(key, p)
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{Content: content, Components: components},
+	})
+}
+
+func pendingEventExpired(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: "This preview has expired; run `/event` again.", Flags: discordgo.MessageFlagsEphemeral},
+	})
+}
+
+// createEventFromPreview creates a previewed event and replaces the preview
+// with the outcome.
+func createEventFromPreview(s *discordgo.Session, i *discordgo.InteractionCreate, guildID, authorID string, spec *eventSpec) {
+	// creating the channel takes a while; acknowledge first and edit when done
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	msg := ""
+	channelName, notice, err := createEventChannel(s, guildID, authorID, spec)
+	if err != nil {
+		log.Printf("Failed to create previewed event: %v", err)
+		msg = "Failed to create event channel."
+	} else {
+		msg = fmt.Sprintf("Event channel '%s' created!", channelName)
+		if notice != "" {
+			msg += " " + notice
+		}
+	}
+	components := []discordgo.MessageComponent{}
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &msg, Components: &components}); err != nil {
+		log.Printf("Failed to report event creation: %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// The Edit modal must prefill a time the parser reads back unchanged.
+func TestPendingEventModalTimeRoundTrips(t *testing.T) {
+	loc := defaultLocation()
+	start := time.Date(2025, 5, 2, 19, 30, 0, 0, loc)
+	end := time.Date(2025, 5, 4, 12, 0, 0, 0, loc)
+	tests := []struct {
+		name string
+		spec *eventSpec
+	}{
+		{name: "start only", spec: &eventSpec{Name: "Poker", Start: start}},
+		{name: "range", spec: &eventSpec{Name: "Trip", Start: start, End: &end}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := pendingEventModal("k", tt.spec)
+			row := resp.Data.Components[1].(*discordgo.ActionsRow)
+			when := row.Components[0].(*discordgo.TextInput).Value
+			gotStart, gotEnd, err := ParseFlexibleRange(when)
+			if err != nil {
+				t.Fatalf("ParseFlexibleRange(%q): %v", when, err)
+			}
+			if !gotStart.Equal(tt.spec.Start) {
+				t.Errorf("start = %v, want %v", gotStart, tt.spec.Start)
+			}
+			if (gotEnd == nil) != (tt.spec.End == nil) || (gotEnd != nil && !gotEnd.Equal(*tt.spec.End)) {
+				t.Errorf("end = %v, want %v", gotEnd, tt.spec.End)
+			}
+		})
+	}
+}
@@ -9,6 +9,15 @@ import (
 	"github.com/bwmarrin/discordgo"
 )
 
+// minCapacity is the smallest attendee limit the capacity options accept.
+var minCapacity = 1.0
+
+// Defaults for events created without a price or emoji.
+const (
+	defaultEventPrice = "Free"
+	defaultEventEmoji = ":loudspeaker:"
+)
+
 func registerEventCreation(s *discordgo.Session, guildID string) {
 	cmd := &discordgo.ApplicationCommand{
 		Name:        "event",
@@ -23,7 +32,7 @@ func registerEventCreation(s *discordgo.Session, guildID string) {
 			{
 				Type:        discordgo.ApplicationCommandOptionString,
 				Name:        "time",
-				Description: "Time/date of the event (flexible formats like YYYY-MM-DD HH:MM:SS; partials accepted e.g. 2025-05)",
+				Description: "Time/date of the event (flexible formats like YYYY-MM-DD HH:MM:SS; ranges like 2025-05-02 to 2025-05-04)",
 				Required:    true,
 			},
 			{
@@ -44,6 +53,82 @@ func registerEventCreation(s *discordgo.Session, guildID string) {
 				Description: "Custom emoji for the event (default: :loudspeaker:)",
 				Required:    false,
 			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "end",
+				Description: "Optional end time or duration (e.g. 21:30, 2025-05-04, 3h, 2d)",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "capacity",
+				Description: "Optional max attendees; extra yeses go on a waitlist",
+				Required:    false,
+				MinValue:    &minCapacity,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "max_guests",
+				Description: "Optional cap on plus-ones per RSVP (0 disallows plus-ones)",
+				Required:    false,
+				MinValue:    &minGuests,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "rsvp_deadline",
+				Description: "Optional RSVP cut-off (flexible formats like YYYY-MM-DD HH:MM)",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "reactions",
+				Description: "Also accept RSVPs via ✅ ❓ ❌ reactions on the event message",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "invitees",
+				Description: "Optional users/roles to invite to the channel (e.g. @alice @bob @friends)",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "announce",
+				Description: "Post a card with a Join button in the announcements channel",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "join_as_maybe",
+				Description: "RSVP people who join via the announcement card as maybe",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "visibility",
+				Description: "Who can see the event channel (default: private)",
+				Required:    false,
+				Choices:     visibilityChoices,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "roles",
+				Description: "Roles that can see a role-restricted event (e.g. @members @friends)",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "confirm",
+				Description: "Preview the event message and confirm before the channel is created",
+				Required:    false,
+			},
+			{
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "preset",
+				Description:  "One of your saved presets to fill in anything not given here",
+				Required:     false,
+				Autocomplete: true,
+			},
 		},
 	}
 
@@ -61,42 +146,234 @@ func handleEventCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	if i.ApplicationCommandData().Name != "event" {
 		return
 	}
-	options := i.ApplicationCommandData().Options
-	var eventName, location, price, emoji string
-	var timeStr string
+	runEventCommand(s, i, parseEventOptions(i.ApplicationCommandData().Options))
+}
+
+// eventOptions are the raw values given to /event.
+type eventOptions struct {
+	Name, Time, Location, Price, Emoji string
+	Notes                              string // only set by presets
+	End, Deadline, Invitees            string
+	Visibility, Roles, Preset          string
+	PresetTitle                        bool // Name is a preset's title pattern
+	Capacity                           int64
+	MaxGuests                          int64 // -1 when not given
+	Reactions, Announce, JoinAsMaybe   bool
+	Confirm                            bool
+}
+
+func parseEventOptions(options []*discordgo.ApplicationCommandInteractionDataOption) *eventOptions {
+	o := &eventOptions{MaxGuests: -1}
 	for _, opt := range options {
 		switch opt.Name {
 		case "event_name":
-			eventName = opt.StringValue()
+			o.Name = opt.StringValue()
 		case "time":
-			timeStr = opt.StringValue()
+			o.Time = opt.StringValue()
 		case "location":
-			location = opt.StringValue()
+			o.Location = opt.StringValue()
 		case "price":
-			price = opt.StringValue()
+			o.Price = opt.StringValue()
 		case "emoji":
-			emoji = opt.StringValue()
+			o.Emoji = opt.StringValue()
+		case "end":
+			o.End = opt.StringValue()
+		case "capacity":
+			o.Capacity = opt.IntValue()
+		case "max_guests":
+			o.MaxGuests = opt.IntValue()
+		case "rsvp_deadline":
+			o.Deadline = opt.StringValue()
+		case "reactions":
+			o.Reactions = opt.BoolValue()
+		case "invitees":
+			o.Invitees = opt.StringValue()
+		case "announce":
+			o.Announce = opt.BoolValue()
+		case "join_as_maybe":
+			o.JoinAsMaybe = opt.BoolValue()
+		case "visibility":
+			o.Visibility = opt.StringValue()
+		case "roles":
+			o.Roles = opt.StringValue()
+		case "confirm":
+			o.Confirm = opt.BoolValue()
+		case "preset":
+			o.Preset = opt.StringValue()
 		}
 	}
-	if price == "" {
-		price = "Free"
-	}
-	if emoji == "" {
-		emoji = ":loudspeaker:"
-	}
+	return o
+}
 
-	// parse flexible time input (several date formats) before creating channel
-	when, perr := ParseFlexibleTime(timeStr)
-	if perr != nil {
+// runEventCommand validates /event's options and creates the event, or
+// previews it first when asked to.
+func runEventCommand(s *discordgo.Session, i *discordgo.InteractionCreate, o *eventOptions) {
+	reply := func(msg string) {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{Content: "Please provide a valid time (formats like YYYY-MM-DD HH:MM:SS).", Flags: discordgo.MessageFlagsEphemeral},
+			Data: &discordgo.InteractionResponseData{Content: msg, Flags: discordgo.MessageFlagsEphemeral},
 		})
+	}
+	if o.Preset != "" {
+		p, err := GetPreset(i.GuildID, i.Member.User.ID, o.Preset)
+		if err != nil {
+			reply(fmt.Sprintf("You have no preset named %q; see `/preset list`.", o.Preset))
+			return
+		}
+		applyPreset(o, p)
+	}
+	if strings.TrimSpace(o.Name) == "" || strings.TrimSpace(o.Location) == "" {
+		reply("Please provide an event name and location, or a preset that has them.")
+		return
+	}
+	// parse flexible time input (several date formats) before creating channel
+	when, end, perr := ParseFlexibleRange(o.Time)
+	if perr != nil {
+		reply("Please provide a valid time (formats like YYYY-MM-DD HH:MM:SS).")
+		return
+	}
+	if o.End != "" {
+		e, eerr := ParseEndInput(when, o.Time, o.End)
+		if eerr != nil {
+			reply("Please provide a valid end after the start (e.g. 21:30, 2025-05-04, 3h).")
+			return
+		}
+		end = &e
+	}
+	var deadline time.Time
+	if o.Deadline != "" {
+		d, derr := ParseFlexibleTime(o.Deadline)
+		if derr != nil {
+			reply("Please provide a valid RSVP deadline (formats like YYYY-MM-DD HH:MM:SS).")
+			return
+		}
+		deadline = d
+	}
+
+	visibility, visibleRoles, verr := parseVisibility(o.Visibility, o.Roles)
+	if verr != nil {
+		reply("Please " + verr.Error() + ".")
+		return
+	}
+
+	name := o.Name
+	if o.PresetTitle {
+		name = expandTitlePattern(name, when)
+	}
+	spec := &eventSpec{
+		Name:         name,
+		Location:     o.Location,
+		Emoji:        o.Emoji,
+		Price:        o.Price,
+		Notes:        o.Notes,
+		Start:        when,
+		End:          end,
+		Capacity:     int(o.Capacity),
+		MaxGuests:    int(o.MaxGuests),
+		Deadline:     deadline,
+		Reactions:    o.Reactions,
+		Announce:     o.Announce,
+		JoinAsMaybe:  o.JoinAsMaybe,
+		Visibility:   visibility,
+		VisibleRoles: visibleRoles,
+	}
+	// Invitees can see and post in the channel from the start.
+	for _, inv := range parseInvitees(o.Invitees) {
+		if inv.TargetID == i.Member.User.ID || inv.TargetID == i.GuildID {
+			continue
+		}
+		spec.Invitees = append(spec.Invitees, inv)
+	}
+	if o.Confirm {
+		confirmEventCreation(s, i, spec)
 		return
 	}
 
+	channelName, notice, err := createEventChannel(s, i.GuildID, i.Member.User.ID, spec)
+	if err != nil {
+		log.Printf("Failed to create event: %v", err)
+		reply("Failed to create event channel.")
+		return
+	}
+	msg := fmt.Sprintf("Event channel '%s' created!", channelName)
+	if notice != "" {
+		msg += " " + notice
+	}
+	reply(msg)
+}
+
+// eventSpec is a validated event waiting to be created. An empty price or
+// emoji gets the usual default.
+type eventSpec struct {
+	Name, Location, Emoji, Price string
+	Notes                        string
+	Start                        time.Time
+	End                          *time.Time
+	Capacity                     int // 0 means unlimited
+	MaxGuests                    int // -1 means not capped, so don't leave it zero
+	Deadline                     time.Time
+	Reactions                    bool
+	Invitees                     []EventInvite
+	Cohosts                      []string // user IDs; they get channel access too
+	Announce, JoinAsMaybe        bool
+	Visibility                   string
+	VisibleRoles                 []string
+}
+
+// previewEvent is the event as it will be created, for rendering a preview
+// and for creating it.
+func (spec *eventSpec) previewEvent(authorID string) *Event {
+	ev := &Event{
+		Emoji:       spec.Emoji,
+		Title:       spec.Name,
+		Location:    spec.Location,
+		Price:       spec.Price,
+		Description: spec.Notes,
+		AuthorID:    authorID,
+		Capacity:    spec.Capacity,
+		MaxGuests:   spec.MaxGuests,
+		Visibility:  spec.Visibility,
+		EndDate:     spec.End,
+
+		VisibilityRoles: spec.VisibleRoles,
+		ReactionRSVP:    spec.Reactions,
+		JoinAsMaybe:     spec.JoinAsMaybe,
+	}
+	if ev.Price == "" {
+		ev.Price = defaultEventPrice
+	}
+	if ev.Emoji == "" {
+		ev.Emoji = defaultEventEmoji
+	}
+	if !spec.Start.IsZero() {
+		start := spec.Start
+		ev.Date = &start
+	}
+	if !spec.Deadline.IsZero() {
+		deadline := spec.Deadline
+		ev.Deadline = &deadline
+	}
+	return ev
+}
+
+// createEventChannel creates the event channel, its events row and the
+// event message. notice carries anything worth telling the organizer that
+// didn't stop the event being created, like a missing announcements channel.
+func createEventChannel(s *discordgo.Session, guildID, authorID string, spec *eventSpec) (channelName, notice string, err error) {
+	if spec.Price == "" {
+		spec.Price = defaultEventPrice
+	}
+	if spec.Emoji == "" {
+		spec.Emoji = defaultEventEmoji
+	}
+	if spec.Visibility == "" {
+		spec.Visibility = "private"
+	}
+	eventName, location, price, emoji := spec.Name, spec.Location, spec.Price, spec.Emoji
+	when, visibility := spec.Start, spec.Visibility
+
 	// Find "Active Plans" category
-	categories, _ := s.GuildChannels(i.GuildID)
+	categories, _ := s.GuildChannels(guildID)
 	var categoryID string
 	for _, c := range categories {
 		if c.Type == discordgo.ChannelTypeGuildCategory && strings.ToLower(c.Name) == "active plans" {
@@ -106,23 +383,21 @@ func handleEventCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	}
 
 	// Set up permissions
-	overwrites := []*discordgo.PermissionOverwrite{
-		{
-			ID:    i.GuildID,
-			Type:  discordgo.PermissionOverwriteTypeRole,
-			Allow: 0,
-			Deny:  discordgo.PermissionViewChannel,
-		},
-		{
-			ID:    i.Member.User.ID,
-			Type:  discordgo.PermissionOverwriteTypeMember,
-			Allow: discordgo.PermissionAllChannel ,
-			Deny:  0,
-		},
+	overwrites := append(visibilityOverwrites(guildID, visibility, spec.VisibleRoles), &discordgo.PermissionOverwrite{
+		ID:    authorID,
+		Type:  discordgo.PermissionOverwriteTypeMember,
+		Allow: discordgo.PermissionAllChannel,
+		Deny:  0,
+	})
+	for _, inv := range spec.Invitees {
+		overwrites = append(overwrites, inviteOverwrite(inv))
+	}
+	for _, id := range spec.Cohosts {
+		overwrites = append(overwrites, inviteOverwrite(EventInvite{TargetID: id, TargetType: "user"}))
 	}
 
-	channelName := strings.ReplaceAll(strings.ToLower(eventName), " ", "-")
-	ch, err := s.GuildChannelCreateComplex(i.GuildID, discordgo.GuildChannelCreateData{
+	channelName = strings.ReplaceAll(strings.ToLower(eventName), " ", "-")
+	ch, err := s.GuildChannelCreateComplex(guildID, discordgo.GuildChannelCreateData{
 		Name:                 channelName,
 		Type:                 discordgo.ChannelTypeGuildText,
 		ParentID:             categoryID,
@@ -130,30 +405,23 @@ func handleEventCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		PermissionOverwrites: overwrites,
 	})
 	if err != nil {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "Failed to create event channel.",
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-		})
-		return
+		return "", "", err
 	}
 
-
-	// Ensure channel is recorded in the DB before inserting the event row. The
-	// events table has a foreign key to channels.discord_channel_id, so we must
-	// upsert the channel first to avoid FK constraint violations.
-	if err := upsertChannel(ch.ID, channelName); err != nil {
-		log.Printf("Failed to upsert channel before persisting event: %v", err)
+	// The events table has a foreign key to channels.discord_channel_id, so
+	// the channel is recorded before the event row. Without the row the event
+	// can't work, so the channel goes again if either fails.
+	ev := spec.previewEvent(authorID)
+	ev.GuildID, ev.ChannelID = guildID, ch.ID
+	err = upsertChannel(ch.ID, channelName)
+	if err == nil {
+		ev.ID, err = CreateEvent(ev, spec.Invitees, spec.Cohosts)
 	}
-
-	// Persist a preliminary event row (message_id unknown yet) so the template renderer
-	// can find the event by channel and populate the template. If this fails we will
-	// fall back to the simple message rendering below.
-	prelimID, perr := CreateEvent(ch.ID, "", emoji, eventName, location, price, i.Member.User.ID, when)
-	if perr != nil {
-		log.Printf("Failed to persist preliminary event to DB: %v", perr)
+	if err != nil {
+		if _, derr := s.ChannelDelete(ch.ID); derr != nil {
+			log.Printf("Failed to delete channel of unsaved event: %v", derr)
+		}
+		return "", "", fmt.Errorf("saving event: %w", err)
 	}
 
 	// Render message from template (reads the event row we just created). If rendering
@@ -165,10 +433,11 @@ func handleEventCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		if !when.IsZero() {
 			timeDisplay = when.Format(time.RFC3339)
 		}
-		rendered = fmt.Sprintf("%s **%s**\nTime: %s\nLocation: %s\nPrice: %s\nCreated by: <@%s>", emoji, eventName, timeDisplay, location, price, i.Member.User.ID)
+		rendered = fmt.Sprintf("%s **%s**\nTime: %s\nLocation: %s\nPrice: %s\nCreated by: <@%s>", emoji, eventName, timeDisplay, location, price, authorID)
 	}
 
-	sent, err := s.ChannelMessageSend(ch.ID, rendered)
+	msg := &discordgo.MessageSend{Content: rendered, Components: rsvpButtons(ev.ID)}
+	sent, err := s.ChannelMessageSendComplex(ch.ID, msg)
 	if err != nil {
 		log.Printf("Failed to send event message: %v", err)
 	} else {
@@ -176,16 +445,11 @@ func handleEventCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		if err := upsertChannel(ch.ID, channelName); err != nil {
 			log.Printf("Failed to upsert channel: %v", err)
 		}
-		// Persist or update the event's message ID. If the preliminary insert succeeded
-		// update the row; otherwise create a new row including the message ID.
-		if perr == nil && prelimID != 0 {
-			if err := UpdateEventFieldByChannel(ch.ID, "message_id", sent.ID); err != nil {
-				log.Printf("Failed to update event message_id: %v", err)
-			}
-		} else {
-			if _, err := CreateEvent(ch.ID, sent.ID, emoji, eventName, location, price, i.Member.User.ID, when); err != nil {
-				log.Printf("Failed to persist event to DB: %v", err)
-			}
+		if err := UpdateEventFieldByChannel(ch.ID, "message_id", sent.ID); err != nil {
+			log.Printf("Failed to update event message_id: %v", err)
+		}
+		if spec.Reactions {
+			seedRSVPReactions(s, ch.ID, sent.ID)
 		}
 		// Record the bot's message in the messages table. onMessageCreate ignores messages from the bot
 		// so we must explicitly insert the initial message sent by the bot here.
@@ -194,11 +458,24 @@ func handleEventCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		}
 	}
 
-	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: fmt.Sprintf("Event channel '%s' created!", channelName),
-			Flags:   discordgo.MessageFlagsEphemeral,
-		},
-	})
+	if spec.Announce {
+		gs, gerr := GetGuildSettings(guildID)
+		ev, everr := GetEventByChannel(ch.ID)
+		switch {
+		case gerr != nil || everr != nil:
+			log.Printf("Failed to load event for announcement: %v %v", gerr, everr)
+			notice = "The announcement card could not be posted."
+		case gs.AnnouncementsChannelID == "":
+			notice = "No announcements channel is configured (see `/settings`), so no card was posted."
+		default:
+			if err := postAnnouncement(s, ev, gs.AnnouncementsChannelID); err != nil {
+				log.Printf("Failed to post announcement card: %v", err)
+				notice = "The announcement card could not be posted."
+			}
+		}
+	}
+	if ev, err := GetEventByChannel(ch.ID); err == nil {
+		syncEventMirrors(s, ev)
+	}
+	return channelName, notice, nil
 }
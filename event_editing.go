@@ -3,12 +3,23 @@ package main
 import (
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
 
+// syncEventMirrors pushes an edited event to the copies kept outside its
+// channel: the announcement card, the Discord scheduled event and the CalDAV
+// calendar. The CalDAV push talks to another server, so it runs in the
+// background to keep interactions within Discord's response window.
+func syncEventMirrors(s *discordgo.Session, ev *Event) {
+	syncAnnouncement(s, ev)
+	syncScheduledEvent(s, ev)
+	go syncCalDAV(s, ev.ID)
+}
+
 func registerEventEditing(s *discordgo.Session, guildID string) {
 	cmd := &discordgo.ApplicationCommand{
 		Name:        "change_name",
@@ -37,9 +48,15 @@ func registerChangeDate(s *discordgo.Session, guildID string) {
 				{
 					Type:        discordgo.ApplicationCommandOptionString,
 					Name:        "new_date",
-					Description: "New date/time of event (flexible formats like YYYY-MM-DD HH:MM:SS)",
+					Description: "New date/time of event (flexible formats like YYYY-MM-DD HH:MM:SS; ranges with 'to')",
 					Required:    true,
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "new_end",
+					Description: "Optional end time or duration (e.g. 21:30, 3h); 'none' clears the end",
+					Required:    false,
+				},
 			},
 	}
 	_, err := s.ApplicationCommandCreate(s.State.User.ID, guildID, cmd)
@@ -55,6 +72,9 @@ func handleChangeNameCommand(s *discordgo.Session, i *discordgo.InteractionCreat
 	if i.ApplicationCommandData().Name != "change_name" {
 		return
 	}
+	if requireEventManager(s, i) == nil {
+		return
+	}
 	var newName string
 	for _, opt := range i.ApplicationCommandData().Options {
 		if opt.Name == "new_name" {
@@ -80,10 +100,9 @@ func handleChangeNameCommand(s *discordgo.Session, i *discordgo.InteractionCreat
 	}
 
 	// re-render and edit the event message
-	if ev, err := GetEventByChannel(channelID); err == nil && ev.MessageID != "" {
-		if rendered, rerr := RenderEventMessage(channelID); rerr == nil {
-			_, _ = s.ChannelMessageEdit(channelID, ev.MessageID, rendered)
-		}
+	if ev, err := GetEventByChannel(channelID); err == nil {
+		refreshEventMessage(s, ev)
+		syncEventMirrors(s, ev)
 	}
 
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
@@ -99,16 +118,23 @@ func handleChangeDateCommand(s *discordgo.Session, i *discordgo.InteractionCreat
 	if i.ApplicationCommandData().Name != "change_date" {
 		return
 	}
-	var newDate string
+	if requireEventManager(s, i) == nil {
+		return
+	}
+	var newDate, newEnd string
 	for _, opt := range i.ApplicationCommandData().Options {
-		if opt.Name == "new_date" {
+		switch opt.Name {
+		case "new_date":
 			newDate = opt.StringValue()
+		case "new_end":
+			newEnd = strings.TrimSpace(opt.StringValue())
 		}
 	}
 	channelID := i.ChannelID
+	rawDate := newDate
 
 	// parse flexible input
-	t, perr := ParseFlexibleTime(newDate)
+	t, end, perr := ParseFlexibleRange(newDate)
 	if perr != nil {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -116,10 +142,32 @@ func handleChangeDateCommand(s *discordgo.Session, i *discordgo.InteractionCreat
 		})
 		return
 	}
-	newDate = t.Format(time.RFC3339)
 
-	// update DB: store as text in "date" column
-	if err := UpdateEventFieldByChannel(channelID, "date", newDate); err != nil {
+	// Work out the new end: an explicit new_end wins, then a range in new_date,
+	// otherwise keep the existing duration so moving an event doesn't drop its end.
+	clearEnd := strings.EqualFold(newEnd, "none")
+	if newEnd != "" && !clearEnd {
+		e, eerr := ParseEndInput(t, rawDate, newEnd)
+		if eerr != nil {
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{Content: "Please provide a valid end after the start (e.g. 21:30, 2025-05-04, 3h).", Flags: discordgo.MessageFlagsEphemeral},
+			})
+			return
+		}
+		end = &e
+	} else if end == nil && !clearEnd {
+		if prev, err := GetEventByChannel(channelID); err == nil && prev.Date != nil && prev.EndDate != nil {
+			e := t.Add(prev.EndDate.Sub(*prev.Date))
+			end = &e
+		}
+	}
+	if clearEnd {
+		// "none" wins over a range in new_date too
+		end = nil
+	}
+
+	if err := UpdateEventDatesByChannel(channelID, t, end); err != nil {
 		log.Printf("Failed to update event date in DB: %v", err)
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -128,10 +176,9 @@ func handleChangeDateCommand(s *discordgo.Session, i *discordgo.InteractionCreat
 		return
 	}
 
-	if ev, err := GetEventByChannel(channelID); err == nil && ev.MessageID != "" {
-		if rendered, rerr := RenderEventMessage(channelID); rerr == nil {
-			_, _ = s.ChannelMessageEdit(channelID, ev.MessageID, rendered)
-		}
+	if ev, err := GetEventByChannel(channelID); err == nil {
+		refreshEventMessage(s, ev)
+		syncEventMirrors(s, ev)
 	}
 
 	// respond with Discord relative timestamp format
@@ -168,6 +215,9 @@ func handleChangeLocationCommand(s *discordgo.Session, i *discordgo.InteractionC
 	if i.ApplicationCommandData().Name != "change_location" {
 		return
 	}
+	if requireEventManager(s, i) == nil {
+		return
+	}
 	var newLocation string
 	for _, opt := range i.ApplicationCommandData().Options {
 		if opt.Name == "new_location" {
@@ -184,10 +234,9 @@ func handleChangeLocationCommand(s *discordgo.Session, i *discordgo.InteractionC
 		})
 		return
 	}
-	if ev, err := GetEventByChannel(channelID); err == nil && ev.MessageID != "" {
-		if rendered, rerr := RenderEventMessage(channelID); rerr == nil {
-			_, _ = s.ChannelMessageEdit(channelID, ev.MessageID, rendered)
-		}
+	if ev, err := GetEventByChannel(channelID); err == nil {
+		refreshEventMessage(s, ev)
+		syncEventMirrors(s, ev)
 	}
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -222,6 +271,9 @@ func handleChangePriceCommand(s *discordgo.Session, i *discordgo.InteractionCrea
 	if i.ApplicationCommandData().Name != "change_price" {
 		return
 	}
+	if requireEventManager(s, i) == nil {
+		return
+	}
 	var newPrice string
 	for _, opt := range i.ApplicationCommandData().Options {
 		if opt.Name == "new_price" {
@@ -238,10 +290,9 @@ func handleChangePriceCommand(s *discordgo.Session, i *discordgo.InteractionCrea
 		})
 		return
 	}
-	if ev, err := GetEventByChannel(channelID); err == nil && ev.MessageID != "" {
-		if rendered, rerr := RenderEventMessage(channelID); rerr == nil {
-			_, _ = s.ChannelMessageEdit(channelID, ev.MessageID, rendered)
-		}
+	if ev, err := GetEventByChannel(channelID); err == nil {
+		refreshEventMessage(s, ev)
+		syncEventMirrors(s, ev)
 	}
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -266,6 +317,9 @@ func handleChangeNotesCommand(s *discordgo.Session, i *discordgo.InteractionCrea
 	// If this is a modal submit for our change_notes modal, handle the save/update flow
 	if i.Type == discordgo.InteractionModalSubmit {
 		if i.ModalSubmitData().CustomID == "change_notes_modal" {
+			if requireEventManager(s, i) == nil {
+				return
+			}
 			channelID := i.ChannelID
 			// extract text input value from modal components
 			var notes string
@@ -290,10 +344,9 @@ func handleChangeNotesCommand(s *discordgo.Session, i *discordgo.InteractionCrea
 				return
 			}
 
-			if ev, err := GetEventByChannel(channelID); err == nil && ev.MessageID != "" {
-				if rendered, rerr := RenderEventMessage(channelID); rerr == nil {
-					_, _ = s.ChannelMessageEdit(channelID, ev.MessageID, rendered)
-				}
+			if ev, err := GetEventByChannel(channelID); err == nil {
+				refreshEventMessage(s, ev)
+				syncEventMirrors(s, ev)
 			}
 
 			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
@@ -313,6 +366,9 @@ func handleChangeNotesCommand(s *discordgo.Session, i *discordgo.InteractionCrea
 	if i.ApplicationCommandData().Name != "change_notes" {
 		return
 	}
+	if requireEventManager(s, i) == nil {
+		return
+	}
 
 	// Respond with a modal asking for notes/description
 	modal := &discordgo.InteractionResponse{
@@ -366,6 +422,9 @@ func handleChangeEmojiCommand(s *discordgo.Session, i *discordgo.InteractionCrea
 	if i.ApplicationCommandData().Name != "change_emoji" {
 		return
 	}
+	if requireEventManager(s, i) == nil {
+		return
+	}
 	var newEmoji string
 	for _, opt := range i.ApplicationCommandData().Options {
 		if opt.Name == "new_emoji" {
@@ -382,13 +441,295 @@ func handleChangeEmojiCommand(s *discordgo.Session, i *discordgo.InteractionCrea
 		})
 		return
 	}
-	if ev, err := GetEventByChannel(channelID); err == nil && ev.MessageID != "" {
-		if rendered, rerr := RenderEventMessage(channelID); rerr == nil {
-			_, _ = s.ChannelMessageEdit(channelID, ev.MessageID, rendered)
-		}
+	if ev, err := GetEventByChannel(channelID); err == nil {
+		refreshEventMessage(s, ev)
+		syncEventMirrors(s, ev)
 	}
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{Content: fmt.Sprintf("Emoji updated to %s", newEmoji), Flags: discordgo.MessageFlagsEphemeral},
 	})
 }
+
+// minNewCapacity is the smallest value /change_capacity accepts; unlike
+// minCapacity it allows 0, which removes the limit.
+var minNewCapacity = 0.0
+
+// Register and handle change_capacity
+func registerChangeCapacity(s *discordgo.Session, guildID string) {
+	cmd := &discordgo.ApplicationCommand{
+		Name:        "change_capacity",
+		Description: "Change the max attendees of the event in the current channel",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "new_capacity",
+				Description: "New max attendees (0 for unlimited)",
+				Required:    true,
+				MinValue:    &minNewCapacity,
+			},
+		},
+	}
+	_, err := s.ApplicationCommandCreate(s.State.User.ID, guildID, cmd)
+	if err != nil {
+		log.Printf("Cannot create '/change_capacity' command: %v", err)
+	}
+}
+
+func handleChangeCapacityCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	if i.ApplicationCommandData().Name != "change_capacity" {
+		return
+	}
+	var newCapacity int64
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name == "new_capacity" {
+			newCapacity = opt.IntValue()
+		}
+	}
+	channelID := i.ChannelID
+	if newCapacity < 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "Capacity can't be negative.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+
+	ev := requireEventManager(s, i)
+	if ev == nil {
+		return
+	}
+	promoted, err := SetEventCapacity(ev.ID, int(newCapacity))
+	if err != nil {
+		log.Printf("Failed to update event capacity in DB: %v", err)
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "Failed to update event capacity in DB.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	if ev, err := GetEventByChannel(channelID); err == nil {
+		refreshEventMessage(s, ev)
+		syncEventMirrors(s, ev)
+	}
+	announcePromotions(s, channelID, promoted)
+
+	msg := "Capacity removed."
+	if newCapacity > 0 {
+		msg = fmt.Sprintf("Capacity updated: %d", newCapacity)
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: msg, Flags: discordgo.MessageFlagsEphemeral},
+	})
+}
+
+// Register and handle change_max_guests
+func registerChangeMaxGuests(s *discordgo.Session, guildID string) {
+	cmd := &discordgo.ApplicationCommand{
+		Name:        "change_max_guests",
+		Description: "Change the plus-one cap per RSVP for the event in the current channel",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "new_max_guests",
+				Description: "New plus-one cap per RSVP (0 disallows plus-ones)",
+				Required:    false,
+				MinValue:    &minGuests,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "remove_cap",
+				Description: "Let everyone bring any number of plus-ones",
+				Required:    false,
+			},
+		},
+	}
+	_, err := s.ApplicationCommandCreate(s.State.User.ID, guildID, cmd)
+	if err != nil {
+		log.Printf("Cannot create '/change_max_guests' command: %v", err)
+	}
+}
+
+func handleChangeMaxGuestsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	if i.ApplicationCommandData().Name != "change_max_guests" {
+		return
+	}
+	newMax := int64(-1)
+	var removeCap bool
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "new_max_guests":
+			newMax = opt.IntValue()
+		case "remove_cap":
+			removeCap = opt.BoolValue()
+		}
+	}
+	if (newMax < 0) == !removeCap {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "Give either a new_max_guests of 0 or more, or remove_cap:True.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	if requireEventManager(s, i) == nil {
+		return
+	}
+	channelID := i.ChannelID
+
+	// an empty value clears the cap
+	value := ""
+	if !removeCap {
+		value = strconv.FormatInt(newMax, 10)
+	}
+	if err := UpdateEventFieldByChannel(channelID, "max_guests", value); err != nil {
+		log.Printf("Failed to update event max guests in DB: %v", err)
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "Failed to update event max guests in DB.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	if ev, err := GetEventByChannel(channelID); err == nil {
+		refreshEventMessage(s, ev)
+		syncEventMirrors(s, ev)
+	}
+	msg := "Plus-one cap removed."
+	if !removeCap {
+		msg = fmt.Sprintf("Plus-one cap updated: %d per RSVP", newMax)
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: msg, Flags: discordgo.MessageFlagsEphemeral},
+	})
+}
+
+// Register and handle change_rsvp_deadline
+func registerChangeRSVPDeadline(s *discordgo.Session, guildID string) {
+	cmd := &discordgo.ApplicationCommand{
+		Name:        "change_rsvp_deadline",
+		Description: "Set, extend or reopen the RSVP deadline of the event in the current channel",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "new_deadline",
+				Description: "New RSVP cut-off (flexible formats like YYYY-MM-DD HH:MM), or 'none' to reopen",
+				Required:    true,
+			},
+		},
+	}
+	_, err := s.ApplicationCommandCreate(s.State.User.ID, guildID, cmd)
+	if err != nil {
+		log.Printf("Cannot create '/change_rsvp_deadline' command: %v", err)
+	}
+}
+
+func handleChangeRSVPDeadlineCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	if i.ApplicationCommandData().Name != "change_rsvp_deadline" {
+		return
+	}
+	var newDeadline string
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name == "new_deadline" {
+			newDeadline = strings.TrimSpace(opt.StringValue())
+		}
+	}
+	channelID := i.ChannelID
+
+	ev := requireEventManager(s, i)
+	if ev == nil {
+		return
+	}
+
+	// "none" (or "open") clears the deadline and reopens RSVPs
+	value := ""
+	msg := "RSVPs reopened with no deadline."
+	if !strings.EqualFold(newDeadline, "none") && !strings.EqualFold(newDeadline, "open") {
+		t, perr := ParseFlexibleTime(newDeadline)
+		if perr != nil {
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{Content: "Please provide a valid time (formats like YYYY-MM-DD HH:MM:SS) or 'none'.", Flags: discordgo.MessageFlagsEphemeral},
+			})
+			return
+		}
+		value = t.Format(time.RFC3339)
+		msg = fmt.Sprintf("RSVP deadline set to <t:%d:f>.", t.Unix())
+	}
+
+	if err := UpdateEventFieldByChannel(channelID, "deadline", value); err != nil {
+		log.Printf("Failed to update event RSVP deadline in DB: %v", err)
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "Failed to update event RSVP deadline in DB.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	if ev, err := GetEventByChannel(channelID); err == nil {
+		refreshEventMessage(s, ev)
+		syncEventMirrors(s, ev)
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: msg, Flags: discordgo.MessageFlagsEphemeral},
+	})
+}
+
+// Register and handle cancel_event
+func registerCancelEvent(s *discordgo.Session, guildID string) {
+	cmd := &discordgo.ApplicationCommand{
+		Name:        "cancel_event",
+		Description: "Cancel the event in the current channel",
+	}
+	_, err := s.ApplicationCommandCreate(s.State.User.ID, guildID, cmd)
+	if err != nil {
+		log.Printf("Cannot create '/cancel_event' command: %v", err)
+	}
+}
+
+func handleCancelEventCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	if i.ApplicationCommandData().Name != "cancel_event" {
+		return
+	}
+	ev := requireEventManager(s, i)
+	if ev == nil {
+		return
+	}
+	channelID := i.ChannelID
+	if ev.Cancelled() {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "This event is already cancelled.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+
+	if err := UpdateEventFieldByChannel(channelID, "status", "cancelled"); err != nil {
+		log.Printf("Failed to cancel event in DB: %v", err)
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "Failed to cancel event in DB.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	if ev, err := GetEventByChannel(channelID); err == nil {
+		refreshEventMessage(s, ev)
+		syncEventMirrors(s, ev)
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: fmt.Sprintf("%s %s has been cancelled.", ev.Emoji, ev.Title)},
+	})
+}
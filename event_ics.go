@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// The "Add to calendar" button on event messages: "ics:<event id>".
+const icsButtonPrefix = "ics:"
+
+var icsFilenameRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// eventICSFile builds a single-event calendar in the default timezone, with
+// its VTIMEZONE, ready to attach. Times are written in UTC when the zone has
+// no IANA name to put in a TZID.
+func eventICSFile(s *discordgo.Session, ev *Event, guildID string) *discordgo.File {
+	loc := icsZone(defaultLocation())
+	cal := newICSCalendar("")
+	if ev.Date != nil && loc != nil {
+		end := *ev.Date
+		if ev.EndDate != nil {
+			end = *ev.EndDate
+		}
+		cal.addTimezone(loc, *ev.Date, end)
+	}
+	organizer := ""
+	if u, err := s.User(ev.AuthorID); err == nil {
+		organizer = u.Username
+		if u.GlobalName != "" {
+			organizer = u.GlobalName
+		}
+	}
+	cal.addZonedEvent(ev, guildID, loc, organizer)
+
+	name := strings.Trim(icsFilenameRe.ReplaceAllString(strings.ToLower(ev.Title), "-"), "-")
+	if name == "" {
+		name = "event"
+	}
+	return &discordgo.File{
+		Name:        name + ".ics",
+		ContentType: "text/calendar",
+		Reader:      strings.NewReader(cal.String()),
+	}
+}
+
+func registerEventICS(s *discordgo.Session, guildID string) {
+	cmd := &discordgo.ApplicationCommand{
+		Name:        "event_ics",
+		Description: "Share a calendar file for the event in the current channel",
+	}
+	_, err := s.ApplicationCommandCreate(s.State.User.ID, guildID, cmd)
+	if err != nil {
+		log.Printf("Cannot create '/event_ics' command: %v", err)
+	}
+}
+
+func handleEventICSCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	if i.ApplicationCommandData().Name != "event_ics" {
+		return
+	}
+	ev, err := GetEventByChannel(i.ChannelID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "Could not find the event record.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	respondWithICS(s, i, ev, 0)
+}
+
+// handleICSButton sends the clicker their own copy of the calendar file.
+func handleICSButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionMessageComponent {
+		return
+	}
+	customID := i.MessageComponentData().CustomID
+	if !strings.HasPrefix(customID, icsButtonPrefix) {
+		return
+	}
+	eventID, err := strconv.ParseInt(strings.TrimPrefix(customID, icsButtonPrefix), 10, 64)
+	if err != nil {
+		return
+	}
+	ev, err := GetEventByID(eventID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "Could not find the event record.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	respondWithICS(s, i, ev, discordgo.MessageFlagsEphemeral)
+}
+
+func respondWithICS(s *discordgo.Session, i *discordgo.InteractionCreate, ev *Event, flags discordgo.MessageFlags) {
+	if ev.Date == nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "This event has no date yet.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("%s %s — open the file to add it to your calendar.", ev.Emoji, ev.Title),
+			Files:   []*discordgo.File{eventICSFile(s, ev, i.GuildID)},
+			Flags:   flags,
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to send calendar file: %v", err)
+	}
+}
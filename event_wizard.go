@@ -0,0 +1,418 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// /event_new walks through creating an event: a modal for the free-text
+// details, then an ephemeral draft with menus for the rest and a live preview
+// of the event message. Nothing is created until the organizer clicks Create.
+//
+// Custom IDs all end in the draft's key: "wizard_modal:<key>" (empty for a
+// new draft), "wizard_date:<key>", "wizard_vis:<key>", "wizard_cap:<key>",
+// "wizard_emoji:<key>", "wizard_create:<key>", "wizard_edit:<key>" and
+// "wizard_cancel:<key>".
+const (
+	wizardModalPrefix  = "wizard_modal:"
+	wizardDatePrefix   = "wizard_date:"
+	wizardVisPrefix    = "wizard_vis:"
+	wizardCapPrefix    = "wizard_cap:"
+	wizardEmojiPrefix  = "wizard_emoji:"
+	wizardCreatePrefix = "wizard_create:"
+	wizardEditPrefix   = "wizard_edit:"
+	wizardCancelPrefix = "wizard_cancel:"
+)
+
+// eventDraft is an event being put together in the wizard.
+type eventDraft struct {
+	GuildID  string
+	AuthorID string
+	Spec     *eventSpec
+	// TimeText is what was typed in the modal's time field.
+	TimeText string
+	// Problem explains why the last input wasn't applied.
+	Problem string
+}
+
+var eventDrafts = newPendingStore[*eventDraft](30 * time.Minute)
+
+// wizardCapacities are the capacity menu's choices; 0 is unlimited.
+var wizardCapacities = []int{0, 4, 6, 8, 10, 12, 16, 20, 30, 50}
+
+// wizardEmojis are the emoji menu's choices, as value and label.
+var wizardEmojis = []struct{ Emoji, Label string }{
+	{defaultEventEmoji, "Announcement"},
+	{"🎉", "Party"},
+	{"🍕", "Food"},
+	{"🍻", "Drinks"},
+	{"☕", "Coffee"},
+	{"🎲", "Board games"},
+	{"🎮", "Video games"},
+	{"🃏", "Cards"},
+	{"🎬", "Movie"},
+	{"🎵", "Music"},
+	{"🏃", "Sports"},
+	{"🥾", "Outdoors"},
+	{"📚", "Book club"},
+}
+
+func registerEventWizard(s *discordgo.Session, guildID string) {
+	cmd := &discordgo.ApplicationCommand{
+		Name:        "event_new",
+		Description: "Create an event step by step, with a preview before anything is posted",
+	}
+	_, err := s.ApplicationCommandCreate(s.State.User.ID, guildID, cmd)
+	if err != nil {
+		log.Printf("Cannot create '/event_new' command: %v", err)
+	}
+}
+
+// wizardModal asks for the free-text details, prefilled from the draft when
+// editing one.
+func wizardModal(key string, d *eventDraft) *discordgo.InteractionResponse {
+	var name, location, when, notes string
+	if d != nil {
+		name, location, when, notes = d.Spec.Name, d.Spec.Location, d.TimeText, d.Spec.Notes
+	}
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: wizardModalPrefix + key,
+			Title:    "New event",
+			Components: []discordgo.MessageComponent{
+				&discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					&discordgo.TextInput{CustomID: "name", Label: "Name", Style: discordgo.TextInputShort, Required: true, MaxLength: 100, Value: name},
+				}},
+				&discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					&discordgo.TextInput{CustomID: "location", Label: "Location", Style: discordgo.TextInputShort, Required: true, MaxLength: 200, Value: location},
+				}},
+				&discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					&discordgo.TextInput{CustomID: "time", Label: "Time (optional; or pick one next)", Style: discordgo.TextInputShort, Required: false, Placeholder: "2025-05-02 19:00 to 22:00", MaxLength: 100, Value: when},
+				}},
+				&discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					&discordgo.TextInput{CustomID: "notes", Label: "Notes", Style: discordgo.TextInputParagraph, Required: false, MaxLength: 1000, Value: notes},
+				}},
+			},
+		},
+	}
+}
+
+func handleEventWizardCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	if i.ApplicationCommandData().Name != "event_new" {
+		return
+	}
+	if err := s.InteractionRespond(i.Interaction, wizardModal("", nil)); err != nil {
+		log.Printf("Failed to open event wizard: %v", err)
+	}
+}
+
+// handleEventWizardModal starts a draft from the modal, or updates the draft
+// it was reopened from.
+func handleEventWizardModal(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionModalSubmit || i.Member == nil {
+		return
+	}
+	key, ok := strings.CutPrefix(i.ModalSubmitData().CustomID, wizardModalPrefix)
+	if !ok {
+		return
+	}
+	values := map[string]string{}
+	for _, row := range i.ModalSubmitData().Components {
+		if ar, ok := row.(*discordgo.ActionsRow); ok {
+			for _, comp := range ar.Components {
+				if ti, ok := comp.(*discordgo.TextInput); ok {
+					values[ti.CustomID] = strings.TrimSpace(ti.Value)
+				}
+			}
+		}
+	}
+
+	var d *eventDraft
+	if key != "" {
+		if d, ok = eventDrafts.get(key); !ok {
+			wizardExpired(s, i)
+			return
+		}
+	} else {
+		d = &eventDraft{
+			GuildID:  i.GuildID,
+			AuthorID: i.Member.User.ID,
+			Spec:     &eventSpec{MaxGuests: -1, Visibility: "private"},
+		}
+	}
+	d.Spec.Name, d.Spec.Location, d.Spec.Notes = values["name"], values["location"], values["notes"]
+	d.TimeText, d.Problem = values["time"], ""
+	if d.TimeText != "" {
+		if start, end, err := ParseFlexibleRange(d.TimeText); err != nil {
+			d.Problem = fmt.Sprintf("Couldn't read the time %q; pick one from the menu or edit the details.", d.TimeText)
+		} else {
+			d.Spec.Start, d.Spec.End = start, end
+		}
+	}
+
+	respType := discordgo.InteractionResponseUpdateMessage
+	if key == "" {
+		key = eventDrafts.put(d)
+		respType = discordgo.InteractionResponseChannelMessageWithSource
+	} else {
+		eventDrafts.set(key, d)
+	}
+	content, components := wizardMessage(key, d)
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: respType,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: components,
+			Flags:      discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to show event draft: %v", err)
+	}
+}
+
+// handleEventWizardComponents applies the draft's menus and buttons.
+func handleEventWizardComponents(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionMessageComponent || i.Member == nil {
+		return
+	}
+	data := i.MessageComponentData()
+	var prefix, key string
+	for _, p := range []string{wizardDatePrefix, wizardVisPrefix, wizardCapPrefix, wizardEmojiPrefix, wizardCreatePrefix, wizardEditPrefix, wizardCancelPrefix} {
+		if k, ok := strings.CutPrefix(data.CustomID, p); ok {
+			prefix, key = p, k
+			break
+		}
+	}
+	if prefix == "" {
+		return
+	}
+	if prefix == wizardCancelPrefix {
+		eventDrafts.take(key)
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{Content: "Event draft discarded.", Components: []discordgo.MessageComponent{}},
+		})
+		return
+	}
+	d, ok := eventDrafts.get(key)
+	if !ok {
+		wizardExpired(s, i)
+		return
+	}
+
+	value := ""
+	if len(data.Values) > 0 {
+		value = data.Values[0]
+	}
+	d.Problem = ""
+	switch prefix {
+	case wizardEditPrefix:
+		if err := s.InteractionRespond(i.Interaction, wizardModal(key, d)); err != nil {
+			log.Printf("Failed to open event wizard: %v", err)
+		}
+		return
+	case wizardCreatePrefix:
+		createFromDraft(s, i, key, d)
+		return
+	case wizardDatePrefix:
+		unix, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return
+		}
+		start := time.Unix(unix, 0)
+		// keep the length of an event that already had an end
+		if d.Spec.End != nil && !d.Spec.Start.IsZero() {
+			end := start.Add(d.Spec.End.Sub(d.Spec.Start))
+			d.Spec.End = &end
+		}
+		d.Spec.Start = start
+	case wizardVisPrefix:
+		d.Spec.Visibility = value
+	case wizardCapPrefix:
+		capacity, err := strconv.Atoi(value)
+		if err != nil {
+			return
+		}
+		d.Spec.Capacity = capacity
+	case wizardEmojiPrefix:
+		d.Spec.Emoji = value
+	}
+	eventDrafts.set(key, d)
+	content, components := wizardMessage(key, d)
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{Content: content, Components: components},
+	})
+}
+
+// createFromDraft creates the event and replaces the draft with the result.
+func createFromDraft(s *discordgo.Session, i *discordgo.InteractionCreate, key string, d *eventDraft) {
+	if d.Spec.Start.IsZero() {
+		d.Problem = "Pick a time first."
+		content, components := wizardMessage(key, d)
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{Content: content, Components: components},
+		})
+		return
+	}
+	// only one click gets the draft, so a double click creates one event
+	d, ok := eventDrafts.take(key)
+	if !ok {
+		wizardExpired(s, i)
+		return
+	}
+	createEventFromPreview(s, i, d.GuildID, d.AuthorID, d.Spec)
+}
+
+func wizardExpired(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: "This draft has expired; run `/event_new` again.", Flags: discordgo.MessageFlagsEphemeral},
+	})
+}
+
+// wizardMessage renders the draft: any problem, the preview of the event
+// message, and the menus and buttons.
+func wizardMessage(key string, d *eventDraft) (string, []discordgo.MessageComponent) {
+	var b strings.Builder
+	b.WriteString("**New event draft** — only you can see this. Adjust it below, then Create.\n")
+	if d.Problem != "" {
+		b.WriteString("⚠️ " + d.Problem + "\n")
+	}
+	b.WriteString("\n")
+	preview, err := RenderEventPreview(d.Spec.previewEvent(d.AuthorID), d.Spec.Invitees, d.Spec.Cohosts)
+	if err != nil {
+		log.Printf("Failed to render event preview: %v", err)
+		preview = "(The preview could not be rendered.)"
+	}
+	b.WriteString(preview)
+	content := truncateRunes(b.String(), maxMessageLength)
+
+	dateOptions := []discordgo.SelectMenuOption{}
+	seen := map[string]bool{}
+	for _, p := range quickDates(time.Now()) {
+		v := strconv.FormatInt(p.At.Unix(), 10)
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		dateOptions = append(dateOptions, discordgo.SelectMenuOption{Label: p.Label, Value: v, Default: p.At.Equal(d.Spec.Start)})
+	}
+	if !d.Spec.Start.IsZero() && !seen[strconv.FormatInt(d.Spec.Start.Unix(), 10)] {
+		current := discordgo.SelectMenuOption{
+			Label:   "As entered: " + d.Spec.Start.In(defaultLocation()).Format("Mon, Jan 2, 3:04 PM"),
+			Value:   strconv.FormatInt(d.Spec.Start.Unix(), 10),
+			Default: true,
+		}
+		dateOptions = append([]discordgo.SelectMenuOption{current}, dateOptions...)
+	}
+
+	visOptions := []discordgo.SelectMenuOption{}
+	for _, c := range visibilityChoices {
+		// role-restricted events need roles; /change_visibility handles those
+		if c.Value == "roles" {
+			continue
+		}
+		v := fmt.Sprint(c.Value)
+		visOptions = append(visOptions, discordgo.SelectMenuOption{Label: c.Name, Value: v, Default: v == d.Spec.Visibility})
+	}
+
+	capOptions := []discordgo.SelectMenuOption{}
+	for _, c := range wizardCapacities {
+		label := fmt.Sprintf("Up to %d attendees", c)
+		if c == 0 {
+			label = "Unlimited attendees"
+		}
+		capOptions = append(capOptions, discordgo.SelectMenuOption{Label: label, Value: strconv.Itoa(c), Default: c == d.Spec.Capacity})
+	}
+
+	emojiOptions := []discordgo.SelectMenuOption{}
+	for _, e := range wizardEmojis {
+		shown := e.Emoji
+		if e.Emoji == defaultEventEmoji {
+			shown = "📢"
+		}
+		selected := e.Emoji == d.Spec.Emoji || (d.Spec.Emoji == "" && e.Emoji == defaultEventEmoji)
+		emojiOptions = append(emojiOptions, discordgo.SelectMenuOption{Label: e.Label, Value: e.Emoji, Emoji: &discordgo.ComponentEmoji{Name: shown}, Default: selected})
+	}
+
+	return content, []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{CustomID: wizardDatePrefix + key, Placeholder: "When?", Options: dateOptions},
+		}},
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{CustomID: wizardVisPrefix + key, Placeholder: "Who can see it?", Options: visOptions},
+		}},
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{CustomID: wizardCapPrefix + key, Placeholder: "How many people?", Options: capOptions},
+		}},
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{CustomID: wizardEmojiPrefix + key, Placeholder: "Emoji", Options: emojiOptions},
+		}},
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Create event", Style: discordgo.SuccessButton, CustomID: wizardCreatePrefix + key, Disabled: d.Spec.Start.IsZero()},
+			discordgo.Button{Label: "Edit details", Style: discordgo.SecondaryButton, CustomID: wizardEditPrefix + key},
+			discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: wizardCancelPrefix + key},
+		}},
+	}
+}
+
+// quickDate is one of the date menu's ready-made choices.
+type quickDate struct {
+	Label string
+	At    time.Time
+}
+
+// quickDates offers common times in the default timezone: tonight, tomorrow
+// evening, and the coming weekend and the one after. Times already past are
+// left out.
+func quickDates(now time.Time) []quickDate {
+	loc := defaultLocation()
+	now = now.In(loc)
+	at := func(days, hour int) time.Time {
+		return time.Date(now.Year(), now.Month(), now.Day()+days, hour, 0, 0, 0, loc)
+	}
+	label := func(prefix string, t time.Time) string {
+		return prefix + ", " + t.Format("Jan 2, 3:04 PM")
+	}
+
+	var out []quickDate
+	if t := at(0, 19); t.After(now) {
+		out = append(out, quickDate{label("Tonight", t), t})
+	}
+	out = append(out, quickDate{label("Tomorrow", at(1, 19)), at(1, 19)})
+	weekend := []struct {
+		Day  time.Weekday
+		Hour int
+	}{
+		{time.Friday, 19},
+		{time.Saturday, 14},
+		{time.Saturday, 19},
+		{time.Sunday, 14},
+	}
+	var next []quickDate
+	for _, w := range weekend {
+		days := (int(w.Day) - int(now.Weekday()) + 7) % 7
+		t := at(days, w.Hour)
+		if !t.After(now) {
+			// today, but the time has passed
+			t = at(days+7, w.Hour)
+		}
+		out = append(out, quickDate{label("This "+w.Day.String(), t), t})
+		t = time.Date(t.Year(), t.Month(), t.Day()+7, w.Hour, 0, 0, 0, loc)
+		next = append(next, quickDate{label("Next "+w.Day.String(), t), t})
+	}
+	out = append(out, next...)
+	return out
+}
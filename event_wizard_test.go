@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuickDates(t *testing.T) {
+	loc := defaultLocation()
+	tests := []struct {
+		name      string
+		now       time.Time
+		wantFirst string
+		wantFri   time.Time
+	}{
+		// Wednesday evening: tonight's slot has passed
+		{name: "evening", now: time.Date(2025, 5, 7, 20, 0, 0, 0, loc), wantFirst: "Tomorrow, May 8, 7:00 PM", wantFri: time.Date(2025, 5, 9, 19, 0, 0, 0, loc)},
+		// Friday morning: this Friday is today
+		{name: "friday", now: time.Date(2025, 5, 9, 10, 0, 0, 0, loc), wantFirst: "Tonight, May 9, 7:00 PM", wantFri: time.Date(2025, 5, 9, 19, 0, 0, 0, loc)},
+		// Friday night: this Friday is a week out
+		{name: "friday night", now: time.Date(2025, 5, 9, 21, 0, 0, 0, loc), wantFirst: "Tomorrow, May 10, 7:00 PM", wantFri: time.Date(2025, 5, 16, 19, 0, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dates := quickDates(tt.now)
+			if len(dates) == 0 || dates[0].Label != tt.wantFirst {
+				t.Fatalf("first choice = %v, want %q", dates, tt.wantFirst)
+			}
+			for _, d := range dates {
+				if !d.At.After(tt.now) {
+					t.Errorf("%q is not in the future", d.Label)
+				}
+				if strings.HasPrefix(d.Label, "This Friday") && !d.At.Equal(tt.wantFri) {
+					t.Errorf("this Friday = %v, want %v", d.At, tt.wantFri)
+				}
+			}
+		})
+	}
+}
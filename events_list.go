@@ -0,0 +1,275 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// eventsPageSize is how many events one page of /events shows.
+const eventsPageSize = 5
+
+// Paging buttons carry the whole query so no state is kept between clicks:
+// "events:<page>:<from unix>:<to unix or 0>:<mine 0|1>:<organizer id>:<tag>".
+const eventsPageButtonPrefix = "events:"
+
+var eventTagRe = regexp.MustCompile(`^[\w-]{1,32}$`)
+
+// eventsQuery is a parsed /events invocation.
+type eventsQuery struct {
+	Page   int
+	Filter EventFilter
+	Mine   bool
+}
+
+func (q eventsQuery) customID(page int) string {
+	to := int64(0)
+	if q.Filter.To != nil {
+		to = q.Filter.To.Unix()
+	}
+	mine := 0
+	if q.Mine {
+		mine = 1
+	}
+	return fmt.Sprintf("%s%d:%d:%d:%d:%s:%s", eventsPageButtonPrefix, page, q.Filter.From.Unix(), to, mine, q.Filter.OrganizerID, q.Filter.Tag)
+}
+
+func parseEventsPageID(customID, userID string) (eventsQuery, bool) {
+	var q eventsQuery
+	if !strings.HasPrefix(customID, eventsPageButtonPrefix) {
+		return q, false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(customID, eventsPageButtonPrefix), ":", 6)
+	if len(parts) != 6 {
+		return q, false
+	}
+	page, err1 := strconv.Atoi(parts[0])
+	from, err2 := strconv.ParseInt(parts[1], 10, 64)
+	to, err3 := strconv.ParseInt(parts[2], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return q, false
+	}
+	q.Page = page
+	q.Filter.From = time.Unix(from, 0)
+	if to != 0 {
+		t := time.Unix(to, 0)
+		q.Filter.To = &t
+	}
+	if parts[3] == "1" {
+		q.Mine = true
+		q.Filter.MemberID = userID
+	}
+	q.Filter.OrganizerID = parts[4]
+	q.Filter.Tag = parts[5]
+	return q, true
+}
+
+func registerEventsList(s *discordgo.Session, guildID string) {
+	cmd := &discordgo.ApplicationCommand{
+		Name:        "events",
+		Description: "List upcoming events",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "from",
+				Description: "Only events on or after this date (e.g. 2025-05-01)",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "to",
+				Description: "Only events starting by this date or within a span (e.g. 2025-05-31, 7d)",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "mine",
+				Description: "Only events you organize or RSVP'd yes/maybe to",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionUser,
+				Name:        "organizer",
+				Description: "Only events organized or co-hosted by this user",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "tag",
+				Description: "Only events with this #tag in the title or notes",
+				Required:    false,
+			},
+		},
+	}
+	_, err := s.ApplicationCommandCreate(s.State.User.ID, guildID, cmd)
+	if err != nil {
+		log.Printf("Cannot create '/events' command: %v", err)
+	}
+}
+
+func handleEventsListCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	if i.ApplicationCommandData().Name != "events" {
+		return
+	}
+	if i.Member == nil {
+		return
+	}
+	var fromStr, toStr string
+	q := eventsQuery{Filter: EventFilter{From: time.Now()}}
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "from":
+			fromStr = opt.StringValue()
+		case "to":
+			toStr = opt.StringValue()
+		case "mine":
+			q.Mine = opt.BoolValue()
+		case "organizer":
+			q.Filter.OrganizerID = fmt.Sprint(opt.Value)
+		case "tag":
+			q.Filter.Tag = strings.TrimPrefix(strings.TrimSpace(opt.StringValue()), "#")
+		}
+	}
+	reply := func(msg string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: msg, Flags: discordgo.MessageFlagsEphemeral},
+		})
+	}
+	if fromStr != "" {
+		from, err := ParseFlexibleTime(fromStr)
+		if err != nil {
+			reply("Please provide a valid `from` date (formats like YYYY-MM-DD).")
+			return
+		}
+		q.Filter.From = from
+	}
+	if toStr != "" {
+		to, err := ParseEndInput(q.Filter.From, fromStr, toStr)
+		if err != nil {
+			reply("Please provide a valid `to` after `from` (e.g. 2025-05-31 or 7d).")
+			return
+		}
+		q.Filter.To = &to
+	}
+	if q.Filter.Tag != "" && !eventTagRe.MatchString(q.Filter.Tag) {
+		reply("Tags are up to 32 letters, digits, `_` or `-`.")
+		return
+	}
+	if q.Mine {
+		q.Filter.MemberID = i.Member.User.ID
+	}
+
+	content, components, err := renderEventsPage(q, i.Member)
+	if err != nil {
+		log.Printf("Failed to list events: %v", err)
+		reply("Failed to list events.")
+		return
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:         content,
+			Components:      components,
+			Flags:           discordgo.MessageFlagsEphemeral,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+// handleEventsPageButton flips the ephemeral /events reply to another page.
+func handleEventsPageButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionMessageComponent || i.Member == nil {
+		return
+	}
+	q, ok := parseEventsPageID(i.MessageComponentData().CustomID, i.Member.User.ID)
+	if !ok {
+		return
+	}
+	content, components, err := renderEventsPage(q, i.Member)
+	if err != nil {
+		log.Printf("Failed to list events: %v", err)
+		content, components = "Failed to list events.", []discordgo.MessageComponent{}
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:         content,
+			Components:      components,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+// renderEventsPage lists one page of the events member can see, with
+// Previous / Next buttons when there is more than one page.
+func renderEventsPage(q eventsQuery, member *discordgo.Member) (string, []discordgo.MessageComponent, error) {
+	all, err := ListEvents(q.Filter)
+	if err != nil {
+		return "", nil, err
+	}
+	var events []*Event
+	for _, ev := range all {
+		if canViewEvent(ev, member) {
+			events = append(events, ev)
+		}
+	}
+	if len(events) == 0 {
+		return "No upcoming events found.", []discordgo.MessageComponent{}, nil
+	}
+	pages := (len(events) + eventsPageSize - 1) / eventsPageSize
+	if q.Page >= pages {
+		q.Page = pages - 1
+	}
+	if q.Page < 0 {
+		q.Page = 0
+	}
+	start := q.Page * eventsPageSize
+	end := start + eventsPageSize
+	if end > len(events) {
+		end = len(events)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "**Upcoming events** (%d)\n", len(events))
+	for _, ev := range events[start:end] {
+		b.WriteString(formatEventListing(ev))
+	}
+	if pages == 1 {
+		return b.String(), []discordgo.MessageComponent{}, nil
+	}
+	fmt.Fprintf(&b, "_Page %d of %d_", q.Page+1, pages)
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Previous", Style: discordgo.SecondaryButton, CustomID: q.customID(q.Page - 1), Disabled: q.Page == 0},
+			discordgo.Button{Label: "Next", Style: discordgo.SecondaryButton, CustomID: q.customID(q.Page + 1), Disabled: q.Page == pages-1},
+		}},
+	}
+	return b.String(), components, nil
+}
+
+// formatEventListing is one entry of /events: emoji, title and time, then
+// location, headcount and a link to the channel.
+func formatEventListing(ev *Event) string {
+	when := "TBD"
+	if ev.Date != nil {
+		when = fmt.Sprintf("<t:%d:f> (<t:%d:R>)", ev.Date.Unix(), ev.Date.Unix())
+	}
+	going, err := GetGoingCount(ev.ID)
+	if err != nil {
+		log.Printf("Failed to count RSVPs: %v", err)
+	}
+	location := ev.Location
+	if location == "" {
+		location = "TBD"
+	}
+	return fmt.Sprintf("%s **%s** — %s\n📍 %s · %d going · <#%s>\n", ev.Emoji, ev.Title, when, location, going, ev.ChannelID)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventsPageIDRoundTrip(t *testing.T) {
+	to := time.Unix(1700100000, 0)
+	tests := []struct {
+		name string
+		q    eventsQuery
+	}{
+		{name: "no filters", q: eventsQuery{Filter: EventFilter{From: time.Unix(1700000000, 0)}}},
+		{name: "all filters", q: eventsQuery{Mine: true, Filter: EventFilter{From: time.Unix(1700000000, 0), To: &to, OrganizerID: "42", MemberID: "7", Tag: "board-games"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseEventsPageID(tt.q.customID(3), "7")
+			if !ok {
+				t.Fatalf("parseEventsPageID(%q) failed", tt.q.customID(3))
+			}
+			if got.Page != 3 || got.Mine != tt.q.Mine || !got.Filter.From.Equal(tt.q.Filter.From) ||
+				got.Filter.OrganizerID != tt.q.Filter.OrganizerID || got.Filter.MemberID != tt.q.Filter.MemberID || got.Filter.Tag != tt.q.Filter.Tag {
+				t.Errorf("got %+v, want %+v on page 3", got, tt.q)
+			}
+			if (got.Filter.To == nil) != (tt.q.Filter.To == nil) || (got.Filter.To != nil && !got.Filter.To.Equal(*tt.q.Filter.To)) {
+				t.Errorf("To = %v, want %v", got.Filter.To, tt.q.Filter.To)
+			}
+		})
+	}
+	for _, id := range []string{"rsvp:1", "events:1:2", "events:x:0:0:0::"} {
+		if _, ok := parseEventsPageID(id, "7"); ok {
+			t.Errorf("parseEventsPageID(%q) should fail", id)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"log"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -26,27 +28,97 @@ func handleHelpCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	}
 	helpMessage := "**Available Commands:**\n" +
 		"1. `/help` - Get a list of available commands.\n" +
-		"2. `/event [name] [time] [location] [emoji] [price]` - Announce an event in the current channel.\n" +
-		"3. `/rsvp [yes/no/maybe] (@user optional) - RSVP to an event; you can RSVP for others by mentioning them (e.g. <@123...>).\n" +
+		"2. `/event [name] [time] [location] [emoji] [price] (end) (capacity) (max_guests) (rsvp_deadline) (reactions) (invitees) (announce) (join_as_maybe) (visibility) (roles) (confirm) (preset)` - Announce an event in the current channel; with `confirm` you see a preview to create, edit or cancel first, and `preset` fills in the optional settings you leave out. Time accepts ranges like `2025-05-02 to 2025-05-04`; end takes a time or duration like `3h`.\n" +
+		"3. `/rsvp [yes/no/maybe] (guests) (@user optional) (comment)` - RSVP to an event; bring plus-ones with +2, add a note like \"arriving late\" (`clear` removes it) and RSVP for others by mentioning them (e.g. <@123...>); organizers only, unless the server lets anyone with the user confirming by DM. You can also use the Going / Maybe / Can't / Note buttons on the event message.\n" +
 		"4. `/change_name [name]` - Change the name of the event.\n" +
-		"5. `/change_date [new_date]` - Change the event's date/time in the current channel.\n" +
+		"5. `/change_date [new_date] (new_end)` - Change the event's date/time in the current channel; the end moves with it unless given.\n" +
 		"6. `/change_location [new_location]` - Change the event location.\n" +
 		"7. `/change_price [new_price]` - Change the event price.\n" +
 		"8. `/change_notes` - Start an interactive notes update (DM flow).\n" +
-		"9. `/change_emoji [new_emoji]` - Change the event emoji.\n"
+		"9. `/change_emoji [new_emoji]` - Change the event emoji.\n" +
+		"10. `/change_capacity [new_capacity]` - Change the max attendees (0 for unlimited); extra yeses wait on a waitlist.\n" +
+		"11. `/change_max_guests (new_max_guests) (remove_cap)` - Cap plus-ones per RSVP (0 disallows) or remove the cap.\n" +
+		"12. `/change_rsvp_deadline [new_deadline]` - Organizer only: set, extend or reopen (`none`) the RSVP cut-off.\n" +
+		"13. `/rsvp_history` - Organizer only: see who changed their RSVP and when, plus headcount over time.\n" +
+		"14. `/settings (proxy_rsvp) (announcements_channel)` - Admin only: view or change server-wide event settings.\n" +
+		"15. `/cohost [add/remove] [user]` - Let someone manage the event with you. Only the organizer, co-hosts and admins can use the `/change_*` commands.\n" +
+		"16. `/invite [@user/@role]` / `/uninvite [@user/@role]` - Give or remove access to the event channel.\n" +
+		"17. `/cancel_event` - Cancel the event; its announcement card is removed.\n" +
+		"18. `/change_visibility [public/private/roles] (roles)` - Change who can see the event channel. Public events are also listed in the server's Events tab, where \"Interested\" counts as a maybe.\n" +
+		"19. `/events (from) (to) (mine) (organizer) (tag)` - List upcoming events you can see; `tag` matches #hashtags in the title or notes.\n" +
+		"20. `/my_events (dm)` - See the events you said yes or maybe to, with buttons to change your RSVP.\n" +
+		"21. `/calendar_feed (reset)` - Get links to subscribe to server events or your own RSVPs from a calendar app.\n" +
+		"22. `/event_ics` - Share a calendar file for the event; the event message's Add to calendar button sends you one privately.\n" +
+		"23. `/import_events [file]` - Create several events from an .ics file or a CSV with `name,time,end,location,price,emoji,notes` columns; you get a preview to confirm first.\n" +
+		"24. `/event_new` - Create an event step by step: fill in the details, pick a date, visibility, capacity and emoji from menus, and check the preview before creating it.\n" +
+		"25. `/preset [save/list/delete/use]` - Save defaults (title, location, price, emoji, notes, visibility, invitees) for events you run often; titles can use `{date}`, `{weekday}` and `{time}`.\n" +
+		"26. `/event_clone [new_time] (invitees) (cohosts) (invite_going) (confirm)` - Organizer only: start a new event copying this one's name, location, price, emoji, notes and visibility; optionally bring the same invitees and co-hosts, and invite everyone who said yes.\n" +
+		"27. `/date_poll start [times]` / `/date_poll finalize [option]` - Organizer only: let people vote yes / if need be / no on 2-5 candidate times separated by `;`, then pick one to set the event date; votes on it become RSVPs.\n"
 
 	// Add poker commands to help
-	helpMessage += "10. `/session [in] [out] (location) (stakes)` - Log a poker session.\n"
-	helpMessage += "11. `/lifetime (user)` - Show lifetime poker stats for a user.\n"
+	helpMessage += "28. `/session [in] [out] (location) (stakes)` - Log a poker session.\n"
+	helpMessage += "29. `/lifetime (user)` - Show lifetime poker stats for a user.\n"
 
+	// The list is longer than one Discord message, so send it in parts.
+	parts := splitMessage(helpMessage, maxMessageLength)
 	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: helpMessage,
+			Content: parts[0],
 			Flags:   discordgo.MessageFlagsEphemeral,
 		},
 	})
 	if err != nil {
 		log.Printf("Failed to send help message: %v", err)
+		return
+	}
+	for _, part := range parts[1:] {
+		if _, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{Content: part, Flags: discordgo.MessageFlagsEphemeral}); err != nil {
+			log.Printf("Failed to send help message: %v", err)
+			return
+		}
+	}
+}
+
+// maxMessageLength is Discord's limit on message content.
+const maxMessageLength = 2000
+
+// splitMessage breaks text into pieces of at most limit bytes, cutting between
+// lines. Discord counts characters, so a byte limit is on the safe side. A
+// single line longer than limit is cut mid-line.
+func splitMessage(text string, limit int) []string {
+	var parts []string
+	var cur strings.Builder
+	for _, line := range strings.SplitAfter(text, "\n") {
+		for len(line) > 0 {
+			if cur.Len()+len(line) <= limit {
+				cur.WriteString(line)
+				break
+			}
+			if cur.Len() > 0 {
+				parts = append(parts, cur.String())
+				cur.Reset()
+				continue
+			}
+			cut := truncateToLimit(line, limit)
+			parts = append(parts, cut)
+			line = line[len(cut):]
+		}
+	}
+	if cur.Len() > 0 || len(parts) == 0 {
+		parts = append(parts, cur.String())
+	}
+	return parts
+}
+
+// truncateToLimit returns the longest prefix of s within limit bytes that
+// doesn't split a UTF-8 character.
+func truncateToLimit(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	for limit > 0 && !utf8.RuneStart(s[limit]) {
+		limit--
 	}
+	return s[:limit]
 }
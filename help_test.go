@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitMessage(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  []string
+	}{
+		{name: "fits", text: "a\nb\n", limit: 10, want: []string{"a\nb\n"}},
+		{name: "empty", text: "", limit: 10, want: []string{""}},
+		{name: "cuts between lines", text: "aaa\nbbb\nccc\n", limit: 8, want: []string{"aaa\nbbb\n", "ccc\n"}},
+		{name: "long line is cut", text: "abcdefghij", limit: 4, want: []string{"abcd", "efgh", "ij"}},
+		{name: "keeps UTF-8 whole", text: "ééé", limit: 3, want: []string{"é", "é", "é"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitMessage(tt.text, tt.limit)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("splitMessage(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+			}
+			for _, part := range got {
+				if len(part) > tt.limit {
+					t.Errorf("part %q is over the limit", part)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// icsProdID identifies the bot in generated calendars.
+const icsProdID = "-//event-bot-2//Events//EN"
+
+// icsCalendar builds an RFC 5545 calendar. Lines are CRLF terminated and
+// folded at 75 octets.
+type icsCalendar struct {
+	b strings.Builder
+}
+
+func newICSCalendar(name string) *icsCalendar {
+	c := &icsCalendar{}
+	c.line("BEGIN", "VCALENDAR")
+	c.line("VERSION", "2.0")
+	c.line("PRODID", icsProdID)
+	c.line("CALSCALE", "GREGORIAN")
+	c.line("METHOD", "PUBLISH")
+	if name != "" {
+		c.line("X-WR-CALNAME", icsText(name))
+	}
+	return c
+}
+
+// line writes "name:value", folding it so no line exceeds 75 octets without
+// splitting a UTF-8 sequence.
+func (c *icsCalendar) line(name, value string) {
+	s := name + ":" + value
+	for len(s) > 75 {
+		cut := 75
+		for cut > 1 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		c.b.WriteString(s[:cut] + "\r\n")
+		// continuation lines start with a space
+		s = " " + s[cut:]
+	}
+	c.b.WriteString(s + "\r\n")
+}
+
+// icsEventUID is stable for the life of the event so calendars update the
+// entry rather than adding a new one.
+func icsEventUID(ev *Event) string {
+	return fmt.Sprintf("event-%d@event-bot-2", ev.ID)
+}
+
+// addEvent writes a VEVENT with times in UTC. guildID is used for the link
+// back to the event channel. Events without a date are skipped.
+func (c *icsCalendar) addEvent(ev *Event, guildID string) {
+	c.writeEvent(ev, guildID, nil, "")
+}
+
+// addZonedEvent writes a VEVENT with times local to loc, which needs a
+// matching addTimezone, and the organizer's name if known. A nil loc writes
+// UTC times.
+func (c *icsCalendar) addZonedEvent(ev *Event, guildID string, loc *time.Location, organizer string) {
+	c.writeEvent(ev, guildID, loc, organizer)
+}
+
+func (c *icsCalendar) writeEvent(ev *Event, guildID string, loc *time.Location, organizer string) {
+	if ev.Date == nil {
+		return
+	}
+	dt := func(name string, t time.Time) {
+		if loc == nil {
+			c.line(name, icsUTC(t))
+		} else {
+			c.line(name+";TZID="+loc.String(), t.In(loc).Format("20060102T150405"))
+		}
+	}
+	c.line("BEGIN", "VEVENT")
+	c.line("UID", icsEventUID(ev))
+	c.line("DTSTAMP", icsUTC(ev.UpdatedAt))
+	c.line("LAST-MODIFIED", icsUTC(ev.UpdatedAt))
+	c.line("SEQUENCE", fmt.Sprint(ev.Sequence))
+	dt("DTSTART", *ev.Date)
+	if ev.EndDate != nil {
+		dt("DTEND", *ev.EndDate)
+	}
+	if organizer != "" {
+		c.line("ORGANIZER;CN="+icsParam(organizer), "https://discord.com/users/"+ev.AuthorID)
+	}
+	c.eventDetails(ev, guildID)
+	c.line("END", "VEVENT")
+}
+
+// icsZone returns loc if it can be named in a TZID, or nil. time.Local is
+// called "Local", which no calendar app can resolve, and UTC needs no zone.
+func icsZone(loc *time.Location) *time.Location {
+	if loc == nil || loc == time.Local || loc.String() == "Local" || loc.String() == "UTC" {
+		return nil
+	}
+	return loc
+}
+
+// addTimezone writes a VTIMEZONE for loc covering the years from through
+// to. Go can't export a zone's rules, so the offset at the start of the span
+// and each change within it are listed as separate observances.
+func (c *icsCalendar) addTimezone(loc *time.Location, from, to time.Time) {
+	type transition struct {
+		at         time.Time
+		name       string
+		fromOffset int
+		toOffset   int
+		dst        bool
+	}
+	start := time.Date(from.In(loc).Year(), 1, 1, 0, 0, 0, 0, loc)
+	end := time.Date(to.In(loc).Year()+1, 1, 1, 0, 0, 0, 0, loc)
+	// the offset in force at the start of the span, so times before the
+	// first change are covered too
+	name, off := start.Zone()
+	transitions := []transition{{at: start, name: name, fromOffset: off, toOffset: off, dst: start.IsDST()}}
+	for day := start; day.Before(end); day = day.Add(24 * time.Hour) {
+		_, before := day.Zone()
+		_, after := day.Add(24 * time.Hour).Zone()
+		if before == after {
+			continue
+		}
+		// narrow down to the second the offset changes
+		lo, hi := day, day.Add(24*time.Hour)
+		for hi.Sub(lo) > time.Second {
+			mid := lo.Add(hi.Sub(lo) / 2)
+			if _, off := mid.Zone(); off == before {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		name, _ := hi.Zone()
+		transitions = append(transitions, transition{at: hi, name: name, fromOffset: before, toOffset: after, dst: hi.IsDST()})
+	}
+
+	c.line("BEGIN", "VTIMEZONE")
+	c.line("TZID", loc.String())
+	for _, t := range transitions {
+		kind := "STANDARD"
+		if t.dst {
+			kind = "DAYLIGHT"
+		}
+		c.line("BEGIN", kind)
+		// onset in local time as it was before the change
+		c.line("DTSTART", t.at.UTC().Add(time.Duration(t.fromOffset)*time.Second).Format("20060102T150405"))
+		c.line("TZOFFSETFROM", icsOffset(t.fromOffset))
+		c.line("TZOFFSETTO", icsOffset(t.toOffset))
+		c.line("TZNAME", icsText(t.name))
+		c.line("END", kind)
+	}
+	c.line("END", "VTIMEZONE")
+}
+
+// eventDetails writes the properties shared by every VEVENT flavour.
+func (c *icsCalendar) eventDetails(ev *Event, guildID string) {
+	c.line("SUMMARY", icsText(icsSummary(ev)))
+	if ev.Location != "" {
+		c.line("LOCATION", icsText(ev.Location))
+	}
+	if ev.Description != "" {
+		c.line("DESCRIPTION", icsText(ev.Description))
+	}
+	if guildID != "" {
+		c.line("URL", fmt.Sprintf("https://discord.com/channels/%s/%s", guildID, ev.ChannelID))
+	}
+	if ev.Cancelled() {
+		c.line("STATUS", "CANCELLED")
+	} else {
+		c.line("STATUS", "CONFIRMED")
+	}
+}
+
+func (c *icsCalendar) String() string {
+	return c.b.String() + "END:VCALENDAR\r\n"
+}
+
+// icsSummary is the event title, prefixed with its emoji when that is a
+// plain Unicode emoji; Discord shortcodes and custom emoji mean nothing to a
+// calendar app.
+func icsSummary(ev *Event) string {
+	if ev.Emoji == "" || strings.HasPrefix(ev.Emoji, ":") || strings.HasPrefix(ev.Emoji, "<") {
+		return ev.Title
+	}
+	return ev.Emoji + " " + ev.Title
+}
+
+func icsUTC(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// icsOffset formats a UTC offset in seconds as +HHMM.
+func icsOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds%3600/60)
+}
+
+// icsParam quotes a parameter value; double quotes aren't allowed inside.
+func icsParam(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "'") + `"`
+}
+
+// icsText escapes a TEXT value.
+func icsText(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return r.Replace(s)
+}
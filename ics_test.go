@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestICSLineFolding(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "short", value: "Poker"},
+		{name: "exactly 75 octets", value: strings.Repeat("x", 75-len("SUMMARY:"))},
+		{name: "long ASCII", value: strings.Repeat("x", 200)},
+		{name: "multi-byte", value: strings.Repeat("é", 100)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &icsCalendar{}
+			c.line("SUMMARY", tt.value)
+			out := c.b.String()
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Fatalf("output %q isn't CRLF terminated", out)
+			}
+			lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+			var unfolded strings.Builder
+			for n, l := range lines {
+				if len(l) > 75 {
+					t.Errorf("line %d is %d octets", n, len(l))
+				}
+				if !utf8.ValidString(l) {
+					t.Errorf("line %d splits a UTF-8 sequence", n)
+				}
+				if n > 0 {
+					if !strings.HasPrefix(l, " ") {
+						t.Fatalf("continuation line %q doesn't start with a space", l)
+					}
+					l = l[1:]
+				}
+				unfolded.WriteString(l)
+			}
+			if want := "SUMMARY:" + tt.value; unfolded.String() != want {
+				t.Errorf("unfolded to %q, want %q", unfolded.String(), want)
+			}
+		})
+	}
+}
+
+func TestICSZone(t *testing.T) {
+	chicago, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	tests := []struct {
+		name string
+		loc  *time.Location
+		want *time.Location
+	}{
+		{name: "named zone", loc: chicago, want: chicago},
+		{name: "local", loc: time.Local, want: nil},
+		{name: "UTC", loc: time.UTC, want: nil},
+		{name: "nil", loc: nil, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := icsZone(tt.loc); got != tt.want {
+				t.Errorf("icsZone(%v) = %v, want %v", tt.loc, got, tt.want)
+			}
+		})
+	}
+}
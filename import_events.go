@@ -0,0 +1,481 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	maxImportBytes = 1 << 20
+	// maxImportEvents caps how many channels one import may create.
+	maxImportEvents = 25
+	// importPreviewLength keeps the preview under Discord's message limit.
+	importPreviewLength = 1800
+)
+
+// Import confirmation buttons: "importconfirm:<key>" / "importcancel:<key>".
+const (
+	importConfirmPrefix = "importconfirm:"
+	importCancelPrefix  = "importcancel:"
+)
+
+// importRow is one entry of an import file: a ready event or why it isn't.
+type importRow struct {
+	Label string // "Row 3" or "Event 2"
+	Spec  *eventSpec
+	Err   error
+}
+
+type pendingImport struct {
+	GuildID  string
+	AuthorID string
+	Rows     []importRow
+}
+
+var pendingImports = newPendingStore[*pendingImport](15 * time.Minute)
+
+func registerImportEvents(s *discordgo.Session, guildID string) {
+	cmd := &discordgo.ApplicationCommand{
+		Name:        "import_events",
+		Description: "Create several events from an .ics or CSV file",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionAttachment,
+				Name:        "file",
+				Description: "An .ics calendar or a CSV with name,time,end,location,price,emoji,notes columns",
+				Required:    true,
+			},
+		},
+	}
+	_, err := s.ApplicationCommandCreate(s.State.User.ID, guildID, cmd)
+	if err != nil {
+		log.Printf("Cannot create '/import_events' command: %v", err)
+	}
+}
+
+func handleImportEventsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	data := i.ApplicationCommandData()
+	if data.Name != "import_events" {
+		return
+	}
+	if i.Member == nil {
+		return
+	}
+	reply := func(msg string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: msg, Flags: discordgo.MessageFlagsEphemeral},
+		})
+	}
+	var att *discordgo.MessageAttachment
+	for _, opt := range data.Options {
+		if opt.Name == "file" && data.Resolved != nil {
+			att = data.Resolved.Attachments[fmt.Sprint(opt.Value)]
+		}
+	}
+	if att == nil {
+		reply("Please attach an .ics or CSV file.")
+		return
+	}
+	if att.Size > maxImportBytes {
+		reply("That file is too large to import (1 MB max).")
+		return
+	}
+	content, err := fetchAttachment(att.URL)
+	if err != nil {
+		log.Printf("Failed to download import file: %v", err)
+		reply("Failed to download the file.")
+		return
+	}
+	rows, err := parseImportFile(att.Filename, content)
+	if err != nil {
+		reply("Could not read the file: " + err.Error())
+		return
+	}
+	if len(rows) == 0 {
+		reply("No events found in the file.")
+		return
+	}
+
+	p := &pendingImport{GuildID: i.GuildID, AuthorID: i.Member.User.ID, Rows: rows}
+	key := pendingImports.put(p)
+	preview, components := importPreview(att.Filename, key, rows)
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:    preview,
+			Components: components,
+			Flags:      discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleImportButtons creates the previewed events or drops the import.
+func handleImportButtons(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionMessageComponent || i.Member == nil {
+		return
+	}
+	customID := i.MessageComponentData().CustomID
+	update := func(msg string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{Content: msg, Components: []discordgo.MessageComponent{}},
+		})
+	}
+	if key, ok := strings.CutPrefix(customID, importCancelPrefix); ok {
+		pendingImports.take(key)
+		update("Import cancelled; nothing was created.")
+		return
+	}
+	key, ok := strings.CutPrefix(customID, importConfirmPrefix)
+	if !ok {
+		return
+	}
+	p, ok := pendingImports.get(key)
+	if !ok {
+		update("This import has expired; run `/import_events` again.")
+		return
+	}
+	if p.AuthorID != i.Member.User.ID {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "Only the person importing can confirm.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	// only one click gets to take the import, so a double click can't
+	// create everything twice
+	if p, ok = pendingImports.take(key); !ok {
+		update("This import has already been handled.")
+		return
+	}
+
+	// creating channels takes a while; acknowledge first and edit when done
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	var b strings.Builder
+	created := 0
+	for _, row := range p.Rows {
+		if row.Err != nil {
+			continue
+		}
+		channelName, _, err := createEventChannel(s, p.GuildID, p.AuthorID, row.Spec)
+		if err != nil {
+			log.Printf("Failed to create imported event: %v", err)
+			fmt.Fprintf(&b, "❌ %s: failed to create the channel\n", row.Label)
+			continue
+		}
+		created++
+		if b.Len() < importPreviewLength {
+			fmt.Fprintf(&b, "✅ %s: #%s\n", row.Label, channelName)
+		}
+	}
+	msg := fmt.Sprintf("**Imported %d event(s).**\n", created) + b.String()
+	components := []discordgo.MessageComponent{}
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &msg, Components: &components}); err != nil {
+		log.Printf("Failed to report import result: %v", err)
+	}
+}
+
+// importPreview lists every row with its outcome, plus Create / Cancel
+// buttons when anything can be created.
+func importPreview(filename, key string, rows []importRow) (string, []discordgo.MessageComponent) {
+	ready := 0
+	for _, r := range rows {
+		if r.Err == nil {
+			ready++
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "**Import preview** (%s): %d ready, %d with errors\n", filename, ready, len(rows)-ready)
+	for n, r := range rows {
+		if b.Len() > importPreviewLength {
+			fmt.Fprintf(&b, "_…and %d more._\n", len(rows)-n)
+			break
+		}
+		if r.Err != nil {
+			fmt.Fprintf(&b, "❌ %s: %v\n", r.Label, r.Err)
+			continue
+		}
+		fmt.Fprintf(&b, "✅ %s: %s **%s** — <t:%d:f>", r.Label, r.Spec.Emoji, r.Spec.Name, r.Spec.Start.Unix())
+		if r.Spec.Location != "" {
+			fmt.Fprintf(&b, " @ %s", r.Spec.Location)
+		}
+		b.WriteString("\n")
+	}
+	if ready == 0 {
+		b.WriteString("Nothing to create; fix the file and try again.")
+		return b.String(), []discordgo.MessageComponent{}
+	}
+	if ready > maxImportEvents {
+		fmt.Fprintf(&b, "At most %d events can be imported at once; split the file.", maxImportEvents)
+		return b.String(), []discordgo.MessageComponent{}
+	}
+	if ready < len(rows) {
+		b.WriteString("Rows with errors will be skipped.")
+	}
+	return b.String(), []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: fmt.Sprintf("Create %d event(s)", ready), Style: discordgo.SuccessButton, CustomID: importConfirmPrefix + key},
+			discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: importCancelPrefix + key},
+		}},
+	}
+}
+
+func fetchAttachment(url string) ([]byte, error) {
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed: %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxImportBytes))
+}
+
+// parseImportFile reads an iCalendar file or a CSV, going by the name and
+// falling back to sniffing the content.
+func parseImportFile(filename string, content []byte) ([]importRow, error) {
+	lower := strings.ToLower(filename)
+	if strings.HasSuffix(lower, ".ics") || bytes.HasPrefix(bytes.TrimSpace(content), []byte("BEGIN:VCALENDAR")) {
+		return parseICSImport(content)
+	}
+	return parseCSVImport(content)
+}
+
+// importSpec applies /event's validation to one entry.
+func importSpec(name, timeStr, endStr, location, price, emoji, notes string) (*eventSpec, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("missing name")
+	}
+	if strings.TrimSpace(timeStr) == "" {
+		return nil, fmt.Errorf("missing time")
+	}
+	if strings.TrimSpace(location) == "" {
+		return nil, fmt.Errorf("missing location")
+	}
+	when, end, err := ParseFlexibleRange(timeStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time %q", timeStr)
+	}
+	if strings.TrimSpace(endStr) != "" {
+		e, err := ParseEndInput(when, timeStr, endStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end %q", endStr)
+		}
+		end = &e
+	}
+	spec := &eventSpec{
+		Name:      name,
+		Location:  strings.TrimSpace(location),
+		Price:     strings.TrimSpace(price),
+		Emoji:     strings.TrimSpace(emoji),
+		Notes:     strings.TrimSpace(notes),
+		Start:     when,
+		End:       end,
+		MaxGuests: -1,
+	}
+	if spec.Price == "" {
+		spec.Price = defaultEventPrice
+	}
+	if spec.Emoji == "" {
+		spec.Emoji = defaultEventEmoji
+	}
+	return spec, nil
+}
+
+// csvImportColumns maps accepted CSV headers to importSpec's fields.
+var csvImportColumns = map[string]string{
+	"name": "name", "event_name": "name", "title": "name",
+	"time": "time", "date": "time", "start": "time",
+	"end":      "end",
+	"location": "location",
+	"price":    "price",
+	"emoji":    "emoji",
+	"notes":    "notes", "description": "notes",
+}
+
+func parseCSVImport(content []byte) ([]importRow, error) {
+	r := csv.NewReader(bytes.NewReader(content))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	cols := map[string]int{}
+	for idx, h := range records[0] {
+		if field, ok := csvImportColumns[strings.ToLower(strings.TrimSpace(h))]; ok {
+			cols[field] = idx
+		}
+	}
+	for _, field := range []string{"name", "time", "location"} {
+		if _, ok := cols[field]; !ok {
+			return nil, fmt.Errorf("the first row must be a header with at least name, time and location columns")
+		}
+	}
+	var rows []importRow
+	for n, rec := range records[1:] {
+		get := func(field string) string {
+			if idx, ok := cols[field]; ok && idx < len(rec) {
+				return rec[idx]
+			}
+			return ""
+		}
+		if strings.TrimSpace(strings.Join(rec, "")) == "" {
+			continue
+		}
+		spec, err := importSpec(get("name"), get("time"), get("end"), get("location"), get("price"), get("emoji"), get("notes"))
+		rows = append(rows, importRow{Label: fmt.Sprintf("Row %d", n+2), Spec: spec, Err: err})
+	}
+	return rows, nil
+}
+
+// icsProperty is one unfolded content line.
+type icsProperty struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+func parseICSImport(content []byte) ([]importRow, error) {
+	var lines []string
+	for _, l := range strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n") {
+		if (strings.HasPrefix(l, " ") || strings.HasPrefix(l, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += l[1:]
+			continue
+		}
+		lines = append(lines, l)
+	}
+
+	var rows []importRow
+	var props map[string]icsProperty
+	for _, l := range lines {
+		p, ok := parseICSLine(l)
+		if !ok {
+			continue
+		}
+		switch {
+		case p.Name == "BEGIN" && p.Value == "VEVENT":
+			props = map[string]icsProperty{}
+		case p.Name == "END" && p.Value == "VEVENT" && props != nil:
+			rows = append(rows, icsImportRow(len(rows)+1, props))
+			props = nil
+		case props != nil:
+			if _, seen := props[p.Name]; !seen {
+				props[p.Name] = p
+			}
+		}
+	}
+	return rows, nil
+}
+
+func icsImportRow(n int, props map[string]icsProperty) importRow {
+	label := fmt.Sprintf("Event %d", n)
+	if summary := props["SUMMARY"].Value; summary != "" {
+		label = fmt.Sprintf("Event %d (%s)", n, icsUnescape(summary))
+	}
+	if strings.EqualFold(props["STATUS"].Value, "CANCELLED") {
+		return importRow{Label: label, Err: fmt.Errorf("cancelled in the file")}
+	}
+	if _, ok := props["RRULE"]; ok {
+		// only the first instance would be created, which is easy to miss
+		return importRow{Label: label, Err: fmt.Errorf("repeating events aren't supported")}
+	}
+	start, err := icsImportTime(props["DTSTART"], false)
+	if err != nil {
+		return importRow{Label: label, Err: fmt.Errorf("invalid DTSTART")}
+	}
+	var end string
+	if p, ok := props["DTEND"]; ok {
+		if end, err = icsImportTime(p, true); err != nil {
+			return importRow{Label: label, Err: fmt.Errorf("invalid DTEND")}
+		}
+	}
+	spec, err := importSpec(icsUnescape(props["SUMMARY"].Value), start, end, icsUnescape(props["LOCATION"].Value), "", "", icsUnescape(props["DESCRIPTION"].Value))
+	return importRow{Label: label, Spec: spec, Err: err}
+}
+
+// icsImportTime turns a DATE or DATE-TIME value into the text the flexible
+// parser reads, as wall time in the default timezone. An all-day DTEND is
+// exclusive, so isEnd steps it back to the last day included.
+func icsImportTime(p icsProperty, isEnd bool) (string, error) {
+	v := p.Value
+	if len(v) == 8 || p.Params["VALUE"] == "DATE" {
+		d, err := time.Parse("20060102", v)
+		if err != nil {
+			return "", err
+		}
+		if isEnd {
+			d = d.AddDate(0, 0, -1)
+		}
+		return d.Format("2006-01-02"), nil
+	}
+	var t time.Time
+	var err error
+	switch {
+	case strings.HasSuffix(v, "Z"):
+		t, err = time.Parse("20060102T150405Z", v)
+	case p.Params["TZID"] != "":
+		loc, lerr := time.LoadLocation(p.Params["TZID"])
+		if lerr != nil {
+			loc = defaultLocation()
+		}
+		t, err = time.ParseInLocation("20060102T150405", v, loc)
+	default:
+		// floating time: read as the default timezone
+		t, err = time.ParseInLocation("20060102T150405", v, defaultLocation())
+	}
+	if err != nil {
+		return "", err
+	}
+	return t.In(defaultLocation()).Format("2006-01-02 15:04:05"), nil
+}
+
+// parseICSLine splits "NAME;PARAM=x:value". Colons inside quoted parameter
+// values don't end the name.
+func parseICSLine(l string) (icsProperty, bool) {
+	idx, quoted := -1, false
+	for n, r := range l {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			idx = n
+			break
+		}
+	}
+	if idx < 0 {
+		return icsProperty{}, false
+	}
+	head := strings.Split(l[:idx], ";")
+	p := icsProperty{Name: strings.ToUpper(head[0]), Params: map[string]string{}, Value: strings.TrimSpace(l[idx+1:])}
+	for _, param := range head[1:] {
+		if k, v, ok := strings.Cut(param, "="); ok {
+			p.Params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return p, true
+}
+
+func icsUnescape(s string) string {
+	r := strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return r.Replace(s)
+}
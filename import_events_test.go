@@ -0,0 +1,176 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// importResult summarises a row as "label: name @ location" or
+// "label: error text" for comparison.
+func importResult(r importRow) string {
+	if r.Err != nil {
+		return r.Label + ": " + r.Err.Error()
+	}
+	return r.Label + ": " + r.Spec.Name + " @ " + r.Spec.Location
+}
+
+func TestParseCSVImport(t *testing.T) {
+	tests := []struct {
+		name     string
+		csv      string
+		want     []string
+		wantFail bool
+	}{
+		{
+			name: "valid rows and header aliases",
+			csv:  "Title,Start,Location,Description\nPoker,2025-05-02 19:00,Alice's,Bring chips\nHike,2025-05-03 08:00 to 12:00,Trailhead,\n",
+			want: []string{"Row 2: Poker @ Alice's", "Row 3: Hike @ Trailhead"},
+		},
+		{
+			name: "blank rows are skipped",
+			csv:  "name,time,location\n,,\nPoker,2025-05-02 19:00,Alice's\n",
+			want: []string{"Row 3: Poker @ Alice's"},
+		},
+		{
+			name: "row errors",
+			csv:  "name,time,end,location\n,2025-05-02,,Home\nPoker,,,Home\nPoker,soon,,Home\nPoker,2025-05-02 19:00,later,Home\nPoker,2025-05-02 19:00,,\n",
+			want: []string{
+				"Row 2: missing name",
+				"Row 3: missing time",
+				`Row 4: invalid time "soon"`,
+				`Row 5: invalid end "later"`,
+				"Row 6: missing location",
+			},
+		},
+		{
+			name:     "location column is required",
+			csv:      "name,time\nPoker,2025-05-02 19:00\n",
+			wantFail: true,
+		},
+		{
+			name:     "no header",
+			csv:      "Poker,2025-05-02 19:00,Home\n",
+			wantFail: true,
+		},
+		{
+			name:     "malformed quoting",
+			csv:      "name,time,location\n\"Poker,2025-05-02,Home\n",
+			wantFail: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := parseCSVImport([]byte(tt.csv))
+			if tt.wantFail {
+				if err == nil {
+					t.Fatalf("got %d rows; want an error", len(rows))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, r := range rows {
+				got = append(got, importResult(r))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestParseICSImport(t *testing.T) {
+	vevent := func(lines ...string) string {
+		return "BEGIN:VEVENT\r\n" + strings.Join(lines, "\r\n") + "\r\nEND:VEVENT\r\n"
+	}
+	calendar := func(events ...string) []byte {
+		return []byte("BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" + strings.Join(events, "") + "END:VCALENDAR\r\n")
+	}
+	tests := []struct {
+		name  string
+		ics   []byte
+		want  []string
+		start string // expected start of the first row, Central Time
+		end   string // expected end of the first row, "" for none
+	}{
+		{
+			name:  "UTC times",
+			ics:   calendar(vevent("SUMMARY:Poker", "LOCATION:Alice's", "DTSTART:20250503T000000Z", "DTEND:20250503T030000Z")),
+			want:  []string{"Event 1 (Poker): Poker @ Alice's"},
+			start: "2025-05-02 19:00:00",
+			end:   "2025-05-02 22:00:00",
+		},
+		{
+			name:  "TZID times",
+			ics:   calendar(vevent("SUMMARY:Poker", "LOCATION:Home", `DTSTART;TZID="America/New_York":20250502T200000`)),
+			want:  []string{"Event 1 (Poker): Poker @ Home"},
+			start: "2025-05-02 19:00:00",
+		},
+		{
+			name:  "all-day end is exclusive",
+			ics:   calendar(vevent("SUMMARY:Camp", "LOCATION:Lake", "DTSTART;VALUE=DATE:20250502", "DTEND;VALUE=DATE:20250505")),
+			want:  []string{"Event 1 (Camp): Camp @ Lake"},
+			start: "2025-05-02 00:00:00",
+			end:   "2025-05-04 23:59:59",
+		},
+		{
+			name:  "folded lines and escapes",
+			ics:   calendar(vevent("SUMMARY:Poker\\, cards", " and chips", "LOCATION:Home\\; upstairs", "DTSTART:20250503T000000Z")),
+			want:  []string{"Event 1 (Poker, cardsand chips): Poker, cardsand chips @ Home; upstairs"},
+			start: "2025-05-02 19:00:00",
+		},
+		{
+			name: "row errors",
+			ics: calendar(
+				vevent("SUMMARY:Gone", "LOCATION:Home", "STATUS:CANCELLED", "DTSTART:20250503T000000Z"),
+				vevent("SUMMARY:Weekly", "LOCATION:Home", "DTSTART:20250503T000000Z", "RRULE:FREQ=WEEKLY"),
+				vevent("SUMMARY:Nowhere", "DTSTART:20250503T000000Z"),
+				vevent("SUMMARY:Broken", "LOCATION:Home", "DTSTART:tomorrow"),
+			),
+			want: []string{
+				"Event 1 (Gone): cancelled in the file",
+				"Event 2 (Weekly): repeating events aren't supported",
+				"Event 3 (Nowhere): missing location",
+				"Event 4 (Broken): invalid DTSTART",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := parseICSImport(tt.ics)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, r := range rows {
+				got = append(got, importResult(r))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Fatalf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			if tt.start == "" {
+				return
+			}
+			spec := rows[0].Spec
+			if want := central(t, tt.start); !spec.Start.Equal(want) {
+				t.Errorf("start = %v, want %v", spec.Start, want)
+			}
+			switch {
+			case tt.end == "" && spec.End != nil:
+				t.Errorf("end = %v, want none", *spec.End)
+			case tt.end != "" && (spec.End == nil || !spec.End.Equal(central(t, tt.end))):
+				t.Errorf("end = %v, want %s", spec.End, tt.end)
+			}
+		})
+	}
+}
+
+func TestParseImportFileSniffsICS(t *testing.T) {
+	ics := []byte("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:Poker\r\nLOCATION:Home\r\nDTSTART:20250503T000000Z\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n")
+	rows, err := parseImportFile("events.txt", ics)
+	if err != nil || len(rows) != 1 || rows[0].Err != nil {
+		t.Fatalf("parseImportFile = %v, %v; want one valid row", rows, err)
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// inviteePermissions is what an invite grants on the event channel: the same
+// access co-hosts get.
+const inviteePermissions = cohostPermissions
+
+var (
+	userMentionRe = regexp.MustCompile(`<@!?(\d+)>`)
+	roleMentionRe = regexp.MustCompile(`<@&(\d+)>`)
+)
+
+// parseInvitees extracts user and role mentions from free text like
+// "<@123> <@&456>".
+func parseInvitees(text string) []EventInvite {
+	var out []EventInvite
+	for _, m := range roleMentionRe.FindAllStringSubmatch(text, -1) {
+		out = append(out, EventInvite{TargetID: m[1], TargetType: "role"})
+	}
+	for _, m := range userMentionRe.FindAllStringSubmatch(text, -1) {
+		out = append(out, EventInvite{TargetID: m[1], TargetType: "user"})
+	}
+	return out
+}
+
+// inviteOverwrite builds the channel permission overwrite for an invitee.
+func inviteOverwrite(inv EventInvite) *discordgo.PermissionOverwrite {
+	t := discordgo.PermissionOverwriteTypeMember
+	if inv.TargetType == "role" {
+		t = discordgo.PermissionOverwriteTypeRole
+	}
+	return &discordgo.PermissionOverwrite{ID: inv.TargetID, Type: t, Allow: inviteePermissions}
+}
+
+// inviteToEvent grants the invitee access to the event channel and records
+// them on the roster.
+func inviteToEvent(s *discordgo.Session, ev *Event, inv EventInvite, invitedBy string) error {
+	ow := inviteOverwrite(inv)
+	if err := s.ChannelPermissionSet(ev.ChannelID, ow.ID, ow.Type, ow.Allow, 0); err != nil {
+		return err
+	}
+	return AddInvite(ev.ID, inv.TargetID, inv.TargetType, invitedBy)
+}
+
+// dropResponsesWithoutAccess removes the RSVPs of responders who can no
+// longer see the event, e.g. after their role was uninvited, so they don't
+// keep holding seats. It returns the users promoted off the waitlist.
+func dropResponsesWithoutAccess(s *discordgo.Session, ev *Event, guildID, actorID string) []string {
+	going, maybe, cant, err := GetResponsesForEvent(ev.ID)
+	if err != nil {
+		log.Printf("Failed to load responses: %v", err)
+		return nil
+	}
+	waitlist, err := GetWaitlistForEvent(ev.ID)
+	if err != nil {
+		log.Printf("Failed to load waitlist: %v", err)
+		return nil
+	}
+	var promoted []string
+	for _, userID := range append(append(append(going, maybe...), cant...), waitlist...) {
+		visible, err := canUserViewEvent(s, ev, guildID, userID)
+		if err != nil {
+			log.Printf("Failed to look up responder: %v", err)
+			continue
+		}
+		if visible {
+			continue
+		}
+		p, err := DeleteResponse(ev.ID, userID, actorID)
+		if err != nil {
+			log.Printf("Failed to remove RSVP of uninvited user: %v", err)
+			continue
+		}
+		promoted = append(promoted, p...)
+	}
+	return promoted
+}
+
+// isInvitedUser reports whether userID is on the event's invite roster in
+// person, not just through a role.
+func isInvitedUser(eventID int64, userID string) (bool, error) {
+	invites, err := GetInvites(eventID)
+	if err != nil {
+		return false, err
+	}
+	for _, inv := range invites {
+		if inv.TargetType == "user" && inv.TargetID == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func inviteMention(inv EventInvite) string {
+	if inv.TargetType == "role" {
+		return fmt.Sprintf("<@&%s>", inv.TargetID)
+	}
+	return fmt.Sprintf("<@%s>", inv.TargetID)
+}
+
+func registerInvites(s *discordgo.Session, guildID string) {
+	for _, cmd := range []*discordgo.ApplicationCommand{
+		{
+			Name:        "invite",
+			Description: "Give a user or role access to the event in the current channel",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionMentionable,
+					Name:        "target",
+					Description: "User or role to invite",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:        "uninvite",
+			Description: "Remove a user's or role's access to the event in the current channel",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionMentionable,
+					Name:        "target",
+					Description: "User or role to uninvite",
+					Required:    true,
+				},
+			},
+		},
+	} {
+		if _, err := s.ApplicationCommandCreate(s.State.User.ID, guildID, cmd); err != nil {
+			log.Printf("Cannot create '/%s' command: %v", cmd.Name, err)
+		}
+	}
+}
+
+func handleInviteCommands(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	data := i.ApplicationCommandData()
+	if data.Name != "invite" && data.Name != "uninvite" {
+		return
+	}
+	ev := requireEventManager(s, i)
+	if ev == nil {
+		return
+	}
+	var inv EventInvite
+	for _, opt := range data.Options {
+		if opt.Name == "target" {
+			inv.TargetID = fmt.Sprint(opt.Value)
+		}
+	}
+	// a mentionable resolves to either a user or a role
+	inv.TargetType = "user"
+	if data.Resolved != nil {
+		if _, ok := data.Resolved.Roles[inv.TargetID]; ok {
+			inv.TargetType = "role"
+		}
+	}
+	if inv.TargetID == i.GuildID {
+		// @everyone's overwrite is what keeps the channel private
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "@everyone can't be invited or uninvited; use `/change_visibility` to open the event up.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	var msg string
+	var promoted []string
+	if data.Name == "invite" {
+		if err := inviteToEvent(s, ev, inv, i.Member.User.ID); err != nil {
+			log.Printf("Failed to invite to event: %v", err)
+			msg = "Failed to invite."
+		} else {
+			msg = fmt.Sprintf("Invited %s.", inviteMention(inv))
+		}
+	} else {
+		if inv.TargetType == "user" && inv.TargetID == ev.AuthorID {
+			msg = "The organizer can't be uninvited."
+		} else if inv.TargetType == "user" && isEventOrganizer(ev, inv.TargetID) {
+			// their overwrite is also what lets them manage the event
+			msg = "Co-hosts can't be uninvited; use `/cohost remove` first."
+		} else if err := s.ChannelPermissionDelete(ev.ChannelID, inv.TargetID); err != nil {
+			log.Printf("Failed to remove channel overwrite: %v", err)
+			msg = "Failed to uninvite."
+		} else if _, err := RemoveInvite(ev.ID, inv.TargetID); err != nil {
+			log.Printf("Failed to remove invite: %v", err)
+			msg = "Failed to uninvite."
+		} else {
+			msg = fmt.Sprintf("Uninvited %s.", inviteMention(inv))
+			promoted = dropResponsesWithoutAccess(s, ev, i.GuildID, i.Member.User.ID)
+		}
+	}
+	refreshEventMessage(s, ev)
+	announcePromotions(s, ev.ChannelID, promoted)
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: msg,
+			Flags:   discordgo.MessageFlagsEphemeral,
+			// don't ping a whole role just to confirm
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseInvitees(t *testing.T) {
+	tests := []struct {
+		text string
+		want []EventInvite
+	}{
+		{text: "", want: nil},
+		{text: "nobody here", want: nil},
+		{text: "<@1> <@!2>", want: []EventInvite{{TargetID: "1", TargetType: "user"}, {TargetID: "2", TargetType: "user"}}},
+		{text: "<@1> <@&9>", want: []EventInvite{{TargetID: "9", TargetType: "role"}, {TargetID: "1", TargetType: "user"}}},
+		{text: "<@&9>,<@&8>", want: []EventInvite{{TargetID: "9", TargetType: "role"}, {TargetID: "8", TargetType: "role"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got := parseInvitees(tt.text)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("parseInvitees(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
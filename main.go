@@ -35,12 +35,18 @@ func main() {
 	if err := InitDB(); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
+	if err := ClaimUnassignedEvents(guildID); err != nil {
+		log.Fatalf("Failed to assign events to the guild: %v", err)
+	}
 	defer func() {
 		if db != nil {
 			_ = db.Close()
 		}
 	}()
 
+	// Optional CalDAV push sync
+	initCalDAV()
+
 	if err := runBot(token, guildID); err != nil {
 		log.Fatalf("Bot error: %v", err)
 	}
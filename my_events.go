@@ -0,0 +1,287 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// myEventsButtonPrefix is for the change buttons under /my_events:
+// "myrsvp:<event id>:<yes|maybe|no>". Unlike the event message buttons they
+// re-render the list rather than the event.
+const myEventsButtonPrefix = "myrsvp:"
+
+// myEventsPastLimit caps how many past events each group lists; only the
+// first few upcoming events get change buttons since a message holds at most
+// five rows.
+const (
+	myEventsPastLimit   = 5
+	myEventsButtonLimit = 5
+	// stop listing before Discord's 2000 character message limit
+	myEventsMaxLength = 1800
+)
+
+func registerMyEvents(s *discordgo.Session, guildID string) {
+	cmd := &discordgo.ApplicationCommand{
+		Name:        "my_events",
+		Description: "List the events you RSVP'd yes or maybe to",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "dm",
+				Description: "Send the list by DM instead of here",
+				Required:    false,
+			},
+		},
+	}
+	_, err := s.ApplicationCommandCreate(s.State.User.ID, guildID, cmd)
+	if err != nil {
+		log.Printf("Cannot create '/my_events' command: %v", err)
+	}
+}
+
+func handleMyEventsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	if i.ApplicationCommandData().Name != "my_events" {
+		return
+	}
+	if i.Member == nil {
+		return
+	}
+	userID := i.Member.User.ID
+	var dm bool
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name == "dm" {
+			dm = opt.BoolValue()
+		}
+	}
+	reply := func(msg string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: msg, Flags: discordgo.MessageFlagsEphemeral},
+		})
+	}
+
+	content, components, err := renderMyEvents(userID)
+	if err != nil {
+		log.Printf("Failed to list user events: %v", err)
+		reply("Failed to load your events.")
+		return
+	}
+	if dm {
+		ch, err := s.UserChannelCreate(userID)
+		if err == nil {
+			_, err = s.ChannelMessageSendComplex(ch.ID, &discordgo.MessageSend{Content: content, Components: components, AllowedMentions: &discordgo.MessageAllowedMentions{}})
+		}
+		if err != nil {
+			log.Printf("Failed to DM event list: %v", err)
+			reply("I couldn't DM you; check your privacy settings.")
+			return
+		}
+		reply("Sent you a DM.")
+		return
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:         content,
+			Components:      components,
+			Flags:           discordgo.MessageFlagsEphemeral,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+// handleMyEventsButton changes an RSVP from the /my_events list, in a guild
+// or a DM, and refreshes both the event message and the list.
+func handleMyEventsButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionMessageComponent {
+		return
+	}
+	customID := i.MessageComponentData().CustomID
+	if !strings.HasPrefix(customID, myEventsButtonPrefix) {
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(customID, myEventsButtonPrefix), ":")
+	if len(parts) != 2 {
+		return
+	}
+	eventID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return
+	}
+	response := parts[1]
+	if _, ok := map[string]bool{"yes": true, "maybe": true, "no": true}[response]; !ok {
+		return
+	}
+	var userID string
+	if i.Member != nil {
+		userID = i.Member.User.ID
+	} else if i.User != nil {
+		userID = i.User.ID
+	} else {
+		return
+	}
+	reply := func(msg string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: msg, Flags: discordgo.MessageFlagsEphemeral},
+		})
+	}
+
+	ev, err := GetEventByID(eventID)
+	if err != nil {
+		reply("Could not find the event record.")
+		return
+	}
+	if ev.Cancelled() {
+		reply("This event has been cancelled.")
+		return
+	}
+	if ev.RSVPClosed() && !canBypassRSVPDeadline(s, ev, userID) {
+		reply(rsvpClosedMessage(ev))
+		return
+	}
+	outcome, err := UpsertResponse(ev.ID, userID, userID, response, -1)
+	if err != nil {
+		log.Printf("Failed to persist RSVP (my events): %v", err)
+		reply("Failed to save RSVP.")
+		return
+	}
+	refreshEventMessage(s, ev)
+	announcePromotions(s, ev.ChannelID, outcome.Promoted)
+
+	content, components, err := renderMyEvents(userID)
+	if err != nil {
+		log.Printf("Failed to list user events: %v", err)
+		reply("RSVP saved, but the list could not be refreshed.")
+		return
+	}
+	note := fmt.Sprintf("RSVP for %s updated: %s", ev.Title, response)
+	if outcome.Waitlisted {
+		note = fmt.Sprintf("%s is full, so you're on the waitlist.", ev.Title)
+	}
+	content = "_" + note + "_\n" + content
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:         content,
+			Components:      components,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+// renderMyEvents groups the user's events into upcoming and past, each split
+// by yes and maybe. Upcoming events are numbered to match their change
+// buttons.
+func renderMyEvents(userID string) (string, []discordgo.MessageComponent, error) {
+	events, err := ListUserEvents(userID)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(events) == 0 {
+		return "You haven't RSVP'd yes or maybe to any events.", []discordgo.MessageComponent{}, nil
+	}
+	now := time.Now()
+	var upYes, upMaybe, pastYes, pastMaybe []UserEvent
+	for _, ue := range events {
+		past := ue.End() != nil && ue.End().Before(now)
+		switch {
+		case !past && ue.Response == "yes":
+			upYes = append(upYes, ue)
+		case !past:
+			upMaybe = append(upMaybe, ue)
+		case ue.Response == "yes":
+			pastYes = append(pastYes, ue)
+		default:
+			pastMaybe = append(pastMaybe, ue)
+		}
+	}
+	// most recent past events first
+	latest := func(list []UserEvent) []UserEvent {
+		var out []UserEvent
+		for k := len(list) - 1; k >= 0 && len(out) < myEventsPastLimit; k-- {
+			out = append(out, list[k])
+		}
+		return out
+	}
+
+	var b strings.Builder
+	var rows []discordgo.MessageComponent
+	n := 0
+	truncated := false
+	section := func(heading string, list []UserEvent, numbered bool) {
+		if len(list) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "**%s**\n", heading)
+		for _, ue := range list {
+			if b.Len() > myEventsMaxLength {
+				truncated = true
+				return
+			}
+			prefix := "•"
+			if numbered {
+				n++
+				prefix = fmt.Sprintf("%d.", n)
+				if len(rows) < myEventsButtonLimit {
+					rows = append(rows, myEventsButtons(n, ue))
+				}
+			}
+			fmt.Fprintf(&b, "%s %s\n", prefix, formatMyEvent(ue))
+		}
+	}
+	section("Upcoming — going", upYes, true)
+	section("Upcoming — maybe", upMaybe, true)
+	section("Past — went", latest(pastYes), false)
+	section("Past — maybe", latest(pastMaybe), false)
+	if truncated {
+		b.WriteString("_…and more._\n")
+	}
+	if n > myEventsButtonLimit {
+		fmt.Fprintf(&b, "_Buttons cover the first %d upcoming events; use the event channel for the rest._\n", myEventsButtonLimit)
+	}
+	if rows == nil {
+		rows = []discordgo.MessageComponent{}
+	}
+	return b.String(), rows, nil
+}
+
+func formatMyEvent(ue UserEvent) string {
+	when := "TBD"
+	if ue.Date != nil {
+		when = fmt.Sprintf("<t:%d:f>", ue.Date.Unix())
+	}
+	line := fmt.Sprintf("%s **%s** — %s · <#%s>", ue.Emoji, ue.Title, when, ue.ChannelID)
+	if ue.Cancelled() {
+		line += " (cancelled)"
+	} else if ue.Waitlisted {
+		line += " (waitlisted)"
+	}
+	return line
+}
+
+// myEventsButtons is the change row for the n-th listed event; the current
+// answer is disabled.
+func myEventsButtons(n int, ue UserEvent) discordgo.ActionsRow {
+	button := func(label, resp string, style discordgo.ButtonStyle) discordgo.Button {
+		return discordgo.Button{
+			Label:    fmt.Sprintf("%d: %s", n, label),
+			Style:    style,
+			CustomID: fmt.Sprintf("%s%d:%s", myEventsButtonPrefix, ue.ID, resp),
+			Disabled: ue.Response == resp || ue.Cancelled(),
+		}
+	}
+	return discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		button("Going", "yes", discordgo.SuccessButton),
+		button("Maybe", "maybe", discordgo.SecondaryButton),
+		button("Can't", "no", discordgo.DangerButton),
+	}}
+}
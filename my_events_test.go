@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestMyEventsButtons(t *testing.T) {
+	tests := []struct {
+		name         string
+		ue           UserEvent
+		wantDisabled []bool
+	}{
+		{name: "going", ue: UserEvent{Event: &Event{ID: 4, Status: "active"}, Response: "yes"}, wantDisabled: []bool{true, false, false}},
+		{name: "maybe", ue: UserEvent{Event: &Event{ID: 4, Status: "active"}, Response: "maybe"}, wantDisabled: []bool{false, true, false}},
+		{name: "cancelled", ue: UserEvent{Event: &Event{ID: 4, Status: "cancelled"}, Response: "yes"}, wantDisabled: []bool{true, true, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := myEventsButtons(2, tt.ue)
+			for n, c := range row.Components {
+				b := c.(discordgo.Button)
+				if b.Disabled != tt.wantDisabled[n] {
+					t.Errorf("button %q disabled = %v, want %v", b.Label, b.Disabled, tt.wantDisabled[n])
+				}
+			}
+			if id := row.Components[0].(discordgo.Button).CustomID; id != "myrsvp:4:yes" {
+				t.Errorf("first button id = %q, want %q", id, "myrsvp:4:yes")
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// cohostPermissions is what being a co-host grants on the event channel, so
+// co-hosts of a private event can see the channel they help run.
+const cohostPermissions = discordgo.PermissionViewChannel | discordgo.PermissionSendMessages |
+	discordgo.PermissionReadMessageHistory | discordgo.PermissionAddReactions
+
+// isEventOrganizer reports whether userID organizes the event: its author or
+// one of its co-hosts. Organizers may respond after RSVPs have closed and
+// RSVP on behalf of others.
+func isEventOrganizer(ev *Event, userID string) bool {
+	if ev == nil {
+		return false
+	}
+	if ev.AuthorID == userID {
+		return true
+	}
+	cohost, err := IsCohost(ev.ID, userID)
+	if err != nil {
+		log.Printf("Failed to check co-host: %v", err)
+		return false
+	}
+	return cohost
+}
+
+// canManageEvent reports whether the interaction's user may edit the event:
+// organizers plus guild admins.
+func canManageEvent(i *discordgo.InteractionCreate, ev *Event) bool {
+	if i.Member == nil || i.Member.User == nil {
+		return false
+	}
+	if i.Member.Permissions&discordgo.PermissionAdministrator != 0 {
+		return true
+	}
+	return isEventOrganizer(ev, i.Member.User.ID)
+}
+
+// canBypassRSVPDeadline reports whether userID may still change RSVPs once
+// the event's deadline has passed: the same organizers and guild admins
+// canManageEvent lets edit it. It works from a user ID so the reaction,
+// message and scheduled event paths, which have no interaction, can use it.
+func canBypassRSVPDeadline(s *discordgo.Session, ev *Event, userID string) bool {
+	if isEventOrganizer(ev, userID) {
+		return true
+	}
+	perms, err := s.UserChannelPermissions(userID, ev.ChannelID)
+	if err != nil {
+		log.Printf("Failed to check permissions: %v", err)
+		return false
+	}
+	return perms&discordgo.PermissionAdministrator != 0
+}
+
+// requireEventManager looks up the event in the interaction's channel and
+// checks the user may edit it. When there's no event or they may not, it
+// replies with an ephemeral explanation and returns nil.
+func requireEventManager(s *discordgo.Session, i *discordgo.InteractionCreate) *Event {
+	ev, err := GetEventByChannel(i.ChannelID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "Could not find the event record.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return nil
+	}
+	if !canManageEvent(i, ev) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "Only the organizer, co-hosts or server admins can do that.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return nil
+	}
+	return ev
+}
+
+func registerCohost(s *discordgo.Session, guildID string) {
+	userOpt := []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Name:        "user",
+			Description: "The co-host",
+			Required:    true,
+		},
+	}
+	cmd := &discordgo.ApplicationCommand{
+		Name:        "cohost",
+		Description: "Manage co-hosts of the event in the current channel",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Let someone manage this event with you",
+				Options:     userOpt,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Remove a co-host from this event",
+				Options:     userOpt,
+			},
+		},
+	}
+	_, err := s.ApplicationCommandCreate(s.State.User.ID, guildID, cmd)
+	if err != nil {
+		log.Printf("Cannot create '/cohost' command: %v", err)
+	}
+}
+
+func handleCohostCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	if i.ApplicationCommandData().Name != "cohost" {
+		return
+	}
+	ev := requireEventManager(s, i)
+	if ev == nil {
+		return
+	}
+	options := i.ApplicationCommandData().Options
+	if len(options) == 0 {
+		return
+	}
+	sub := options[0]
+	var user *discordgo.User
+	for _, opt := range sub.Options {
+		if opt.Name == "user" {
+			user = opt.UserValue(s)
+		}
+	}
+	if user == nil {
+		return
+	}
+
+	var msg string
+	switch sub.Name {
+	case "add":
+		if user.ID == ev.AuthorID {
+			msg = fmt.Sprintf("<@%s> already organizes this event.", user.ID)
+			break
+		}
+		if err := s.ChannelPermissionSet(ev.ChannelID, user.ID, discordgo.PermissionOverwriteTypeMember, cohostPermissions, 0); err != nil {
+			log.Printf("Failed to give co-host channel access: %v", err)
+			msg = "Failed to add co-host."
+			break
+		}
+		if err := AddCohost(ev.ID, user.ID, i.Member.User.ID); err != nil {
+			log.Printf("Failed to add co-host: %v", err)
+			msg = "Failed to add co-host."
+			break
+		}
+		msg = fmt.Sprintf("<@%s> is now a co-host.", user.ID)
+	case "remove":
+		removed, err := RemoveCohost(ev.ID, user.ID)
+		if err != nil {
+			log.Printf("Failed to remove co-host: %v", err)
+			msg = "Failed to remove co-host."
+			break
+		}
+		if !removed {
+			msg = fmt.Sprintf("<@%s> isn't a co-host.", user.ID)
+			break
+		}
+		// an invitee keeps the access their invite gave them
+		if invited, err := isInvitedUser(ev.ID, user.ID); err != nil {
+			log.Printf("Failed to load invites: %v", err)
+		} else if !invited {
+			if err := s.ChannelPermissionDelete(ev.ChannelID, user.ID); err != nil {
+				log.Printf("Failed to remove co-host channel access: %v", err)
+			}
+		}
+		msg = fmt.Sprintf("<@%s> is no longer a co-host.", user.ID)
+	}
+	refreshEventMessage(s, ev)
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: msg, Flags: discordgo.MessageFlagsEphemeral},
+	})
+}
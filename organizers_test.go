@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestCanManageEvent(t *testing.T) {
+	ev := &Event{ID: 1, AuthorID: "author"}
+	member := func(id string, perms int64) *discordgo.InteractionCreate {
+		return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			Member: &discordgo.Member{User: &discordgo.User{ID: id}, Permissions: perms},
+		}}
+	}
+	tests := []struct {
+		name string
+		i    *discordgo.InteractionCreate
+		want bool
+	}{
+		{name: "author", i: member("author", 0), want: true},
+		{name: "admin", i: member("someone", discordgo.PermissionAdministrator), want: true},
+		{name: "outside a guild", i: &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{}}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canManageEvent(tt.i, ev); got != tt.want {
+				t.Errorf("canManageEvent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// pendingStore holds short-lived interaction state, like an import waiting
+// for confirmation, under a random key that goes into button custom IDs.
+// Entries expire after ttl; a restart forgets them all.
+type pendingStore[T any] struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	items map[string]pendingEntry[T]
+}
+
+type pendingEntry[T any] struct {
+	value   T
+	expires time.Time
+}
+
+func newPendingStore[T any](ttl time.Duration) *pendingStore[T] {
+	return &pendingStore[T]{ttl: ttl, items: map[string]pendingEntry[T]{}}
+}
+
+// put stores v under a new key and returns the key.
+func (p *pendingStore[T]) put(v T) string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	key := hex.EncodeToString(buf)
+	p.set(key, v)
+	return key
+}
+
+// set stores v under key, restarting its expiry.
+func (p *pendingStore[T]) set(key string, v T) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	for k, e := range p.items {
+		if now.After(e.expires) {
+			delete(p.items, k)
+		}
+	}
+	p.items[key] = pendingEntry[T]{value: v, expires: now.Add(p.ttl)}
+}
+
+func (p *pendingStore[T]) get(key string) (T, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e, ok := p.items[key]
+	if !ok || time.Now().After(e.expires) {
+		var zero T
+		return zero, false
+	}
+	return e.value, true
+}
+
+// take removes and returns the entry, so only one click can act on it.
+func (p *pendingStore[T]) take(key string) (T, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e, ok := p.items[key]
+	delete(p.items, key)
+	if !ok || time.Now().After(e.expires) {
+		var zero T
+		return zero, false
+	}
+	return e.value, true
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Presets save /event defaults for events that happen again and again. They
+// belong to the user who saved them, in that guild only.
+
+// maxPresetName keeps preset names short enough for autocomplete.
+const maxPresetName = 50
+
+// expandTitlePattern fills in a preset title's placeholders from the event's
+// start, e.g. "Poker night {date}".
+func expandTitlePattern(pattern string, start time.Time) string {
+	t := start.In(defaultLocation())
+	return strings.NewReplacer(
+		"{date}", t.Format("Jan 2"),
+		"{weekday}", t.Weekday().String(),
+		"{time}", t.Format("3:04 PM"),
+	).Replace(pattern)
+}
+
+// applyPreset fills in whatever the /event options left empty. Only a title
+// taken from the preset is treated as a pattern; one the user typed is used
+// as is.
+func applyPreset(o *eventOptions, p *EventPreset) {
+	fill := func(dst *string, v string) {
+		if *dst == "" {
+			*dst = v
+		}
+	}
+	if o.Name == "" && p.Title != "" {
+		o.Name, o.PresetTitle = p.Title, true
+	}
+	fill(&o.Location, p.Location)
+	fill(&o.Price, p.Price)
+	fill(&o.Emoji, p.Emoji)
+	fill(&o.Notes, p.Notes)
+	fill(&o.Invitees, p.Invitees)
+	if o.Visibility == "" {
+		o.Visibility, o.Roles = p.Visibility, p.Roles
+	}
+}
+
+func registerPresets(s *discordgo.Session, guildID string) {
+	nameOpt := &discordgo.ApplicationCommandOption{
+		Type:         discordgo.ApplicationCommandOptionString,
+		Name:         "name",
+		Description:  "The preset's name",
+		Required:     true,
+		Autocomplete: true,
+	}
+	cmd := &discordgo.ApplicationCommand{
+		Name:        "preset",
+		Description: "Save and reuse defaults for events you run often",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "save",
+				Description: "Save a preset (replaces one with the same name)",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "Name to save the preset under",
+						Required:    true,
+						MaxLength:   maxPresetName,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "title",
+						Description: "Event name; {date}, {weekday} and {time} are filled in (e.g. Poker night {date})",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "location",
+						Description: "Location of the event",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "price",
+						Description: "Price of the event",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "emoji",
+						Description: "Emoji for the event",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "notes",
+						Description: "Notes for the event",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "visibility",
+						Description: "Who can see the event channel",
+						Required:    false,
+						Choices:     visibilityChoices,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "roles",
+						Description: "Roles that can see a role-restricted event (e.g. @members @friends)",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "invitees",
+						Description: "Users/roles to invite (e.g. @alice @bob @friends)",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List your presets",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "delete",
+				Description: "Delete a preset",
+				Options:     []*discordgo.ApplicationCommandOption{nameOpt},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "use",
+				Description: "Create an event from a preset",
+				Options: []*discordgo.ApplicationCommandOption{
+					nameOpt,
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "time",
+						Description: "Time/date of the event (flexible formats like YYYY-MM-DD HH:MM; ranges allowed)",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "confirm",
+						Description: "Preview the event message and confirm before the channel is created",
+						Required:    false,
+					},
+				},
+			},
+		},
+	}
+	_, err := s.ApplicationCommandCreate(s.State.User.ID, guildID, cmd)
+	if err != nil {
+		log.Printf("Cannot create '/preset' command: %v", err)
+	}
+}
+
+func handlePresetCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	if i.ApplicationCommandData().Name != "preset" || i.Member == nil {
+		return
+	}
+	options := i.ApplicationCommandData().Options
+	if len(options) == 0 {
+		return
+	}
+	sub := options[0]
+	values := map[string]string{}
+	for _, opt := range sub.Options {
+		values[opt.Name] = fmt.Sprint(opt.Value)
+	}
+	reply := func(msg string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: msg, Flags: discordgo.MessageFlagsEphemeral},
+		})
+	}
+	userID := i.Member.User.ID
+	name := strings.TrimSpace(values["name"])
+
+	switch sub.Name {
+	case "save":
+		if name == "" {
+			reply("Please give the preset a name.")
+			return
+		}
+		if values["visibility"] != "" {
+			if _, _, err := parseVisibility(values["visibility"], values["roles"]); err != nil {
+				reply("Please " + err.Error() + ".")
+				return
+			}
+		}
+		p := &EventPreset{
+			GuildID:    i.GuildID,
+			UserID:     userID,
+			Name:       name,
+			Title:      values["title"],
+			Location:   values["location"],
+			Price:      values["price"],
+			Emoji:      values["emoji"],
+			Notes:      values["notes"],
+			Visibility: values["visibility"],
+			Roles:      values["roles"],
+			Invitees:   values["invitees"],
+		}
+		if err := SavePreset(p); err != nil {
+			log.Printf("Failed to save preset: %v", err)
+			reply("Failed to save the preset.")
+			return
+		}
+		reply(fmt.Sprintf("Saved preset **%s**. Use it with `/preset use` or `/event preset:%s`.", name, name))
+	case "list":
+		presets, err := ListPresets(i.GuildID, userID)
+		if err != nil {
+			log.Printf("Failed to list presets: %v", err)
+			reply("Failed to load your presets.")
+			return
+		}
+		if len(presets) == 0 {
+			reply("You have no presets yet; save one with `/preset save`.")
+			return
+		}
+		var b strings.Builder
+		b.WriteString("**Your presets:**\n")
+		for _, p := range presets {
+			b.WriteString("• " + describePreset(p) + "\n")
+		}
+		reply(truncateRunes(b.String(), maxMessageLength))
+	case "delete":
+		deleted, err := DeletePreset(i.GuildID, userID, name)
+		if err != nil {
+			log.Printf("Failed to delete preset: %v", err)
+			reply("Failed to delete the preset.")
+			return
+		}
+		if !deleted {
+			reply(fmt.Sprintf("You have no preset named %q.", name))
+			return
+		}
+		reply(fmt.Sprintf("Deleted preset **%s**.", name))
+	case "use":
+		runEventCommand(s, i, &eventOptions{
+			Preset:    name,
+			Time:      values["time"],
+			MaxGuests: -1,
+			Confirm:   values["confirm"] == "true",
+		})
+	}
+}
+
+// describePreset lists what a preset fills in.
+func describePreset(p *EventPreset) string {
+	var parts []string
+	add := func(label, v string) {
+		if v != "" {
+			parts = append(parts, label+v)
+		}
+	}
+	add("", p.Emoji)
+	add("", p.Title)
+	add("at ", p.Location)
+	add("price ", p.Price)
+	add("", p.Visibility)
+	add("roles ", p.Roles)
+	add("invites ", p.Invitees)
+	if p.Notes != "" {
+		parts = append(parts, "with notes")
+	}
+	if len(parts) == 0 {
+		return "**" + p.Name + "**"
+	}
+	return "**" + p.Name + "** — " + strings.Join(parts, " · ")
+}
+
+// handlePresetAutocomplete suggests the user's presets for /event preset and
+// /preset's name options.
+func handlePresetAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommandAutocomplete || i.Member == nil {
+		return
+	}
+	data := i.ApplicationCommandData()
+	options := data.Options
+	switch data.Name {
+	case "event":
+	case "preset":
+		if len(options) == 0 {
+			return
+		}
+		options = options[0].Options
+	default:
+		return
+	}
+	typed := ""
+	focused := false
+	for _, opt := range options {
+		if opt.Focused && (opt.Name == "preset" || opt.Name == "name") {
+			typed, focused = strings.ToLower(opt.StringValue()), true
+		}
+	}
+	if !focused {
+		return
+	}
+	presets, err := ListPresets(i.GuildID, i.Member.User.ID)
+	if err != nil {
+		log.Printf("Failed to list presets: %v", err)
+	}
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	for _, p := range presets {
+		if !strings.Contains(strings.ToLower(p.Name), typed) {
+			continue
+		}
+		label := p.Name
+		if p.Title != "" {
+			label += " — " + p.Title
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: truncateRunes(label, 100), Value: p.Name})
+		if len(choices) == 25 {
+			break
+		}
+	}
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{Choices: choices},
+	})
+	if err != nil {
+		log.Printf("Failed to send preset suggestions: %v", err)
+	}
+}
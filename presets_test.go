@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpandTitlePattern(t *testing.T) {
+	loc := defaultLocation()
+	start := time.Date(2025, 5, 2, 19, 30, 0, 0, loc)
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{pattern: "Poker night", want: "Poker night"},
+		{pattern: "Poker {date}", want: "Poker May 2"},
+		{pattern: "{weekday} games at {time}", want: "Friday games at 7:30 PM"},
+		{pattern: "{unknown}", want: "{unknown}"},
+	}
+	for _, tt := range tests {
+		if got := expandTitlePattern(tt.pattern, start); got != tt.want {
+			t.Errorf("expandTitlePattern(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestApplyPreset(t *testing.T) {
+	p := &EventPreset{Title: "Poker {date}", Location: "Home", Price: "$5", Visibility: "roles", Roles: "<@&1>"}
+	tests := []struct {
+		name            string
+		o               eventOptions
+		wantName        string
+		wantPresetTitle bool
+		wantLocation    string
+		wantVisibility  string
+	}{
+		{name: "fills blanks", o: eventOptions{}, wantName: "Poker {date}", wantPresetTitle: true, wantLocation: "Home", wantVisibility: "roles"},
+		{name: "typed values win", o: eventOptions{Name: "Cards {date}", Location: "Bar", Visibility: "public"}, wantName: "Cards {date}", wantLocation: "Bar", wantVisibility: "public"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := tt.o
+			applyPreset(&o, p)
+			if o.Name != tt.wantName || o.PresetTitle != tt.wantPresetTitle || o.Location != tt.wantLocation || o.Visibility != tt.wantVisibility {
+				t.Errorf("got name %q (pattern %v), location %q, visibility %q; want %q (%v), %q, %q",
+					o.Name, o.PresetTitle, o.Location, o.Visibility, tt.wantName, tt.wantPresetTitle, tt.wantLocation, tt.wantVisibility)
+			}
+			if o.Price != "$5" {
+				t.Errorf("price = %q, want the preset's", o.Price)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Confirmation buttons DMed to the target of a proxy RSVP carry the pending
+// request id: "proxyconfirm:<id>" / "proxydecline:<id>".
+const (
+	proxyConfirmPrefix = "proxyconfirm:"
+	proxyDeclinePrefix = "proxydecline:"
+)
+
+// proxyRSVPRefusal returns why actorID may not RSVP for userID directly, or ""
+// when they may. needsConfirm is true when the guild allows anyone to proxy
+// but the target has to confirm first.
+func proxyRSVPRefusal(ev *Event, guildID, actorID, userID string) (refusal string, needsConfirm bool) {
+	if userID == actorID || isEventOrganizer(ev, actorID) {
+		return "", false
+	}
+	gs, err := GetGuildSettings(guildID)
+	if err != nil {
+		log.Printf("Failed to load guild settings: %v", err)
+		return "Could not check whether you may RSVP for others.", false
+	}
+	if !gs.OpenProxyRSVP {
+		return "Only organizers can RSVP for someone else.", false
+	}
+	return "", true
+}
+
+// requestProxyConfirmation records a pending proxy RSVP and DMs the target
+// user Confirm / Decline buttons. It returns the reply for the requester.
+func requestProxyConfirmation(s *discordgo.Session, ev *Event, userID, actorID, response string, guests int) string {
+	id, err := CreatePendingProxyRSVP(ev.ID, userID, actorID, response, guests)
+	if err != nil {
+		log.Printf("Failed to store pending proxy RSVP: %v", err)
+		return "Failed to save RSVP."
+	}
+	refreshEventMessage(s, ev)
+
+	dm, err := s.UserChannelCreate(userID)
+	if err == nil {
+		_, err = s.ChannelMessageSendComplex(dm.ID, &discordgo.MessageSend{
+			Content: fmt.Sprintf("<@%s> RSVP'd **%s** for you to %s %s. Does that count?", actorID, response, ev.Emoji, ev.Title),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.Button{Label: "Confirm", Style: discordgo.SuccessButton, CustomID: fmt.Sprintf("%s%d", proxyConfirmPrefix, id)},
+					discordgo.Button{Label: "Decline", Style: discordgo.DangerButton, CustomID: fmt.Sprintf("%s%d", proxyDeclinePrefix, id)},
+				}},
+			},
+		})
+	}
+	if err != nil {
+		log.Printf("Failed to DM proxy RSVP confirmation: %v", err)
+		return fmt.Sprintf("RSVP for <@%s> is pending, but I couldn't DM them to confirm. It counts once they RSVP themselves.", userID)
+	}
+	return fmt.Sprintf("Asked <@%s> to confirm your RSVP (%s) for them.", userID, response)
+}
+
+func handleProxyConfirmButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionMessageComponent {
+		return
+	}
+	customID := i.MessageComponentData().CustomID
+	var confirm bool
+	var rest string
+	switch {
+	case strings.HasPrefix(customID, proxyConfirmPrefix):
+		confirm, rest = true, strings.TrimPrefix(customID, proxyConfirmPrefix)
+	case strings.HasPrefix(customID, proxyDeclinePrefix):
+		rest = strings.TrimPrefix(customID, proxyDeclinePrefix)
+	default:
+		return
+	}
+	id, err := strconv.ParseInt(rest, 10, 64)
+	if err != nil {
+		return
+	}
+	// buttons arrive in DMs, where the user isn't wrapped in a Member
+	user := i.User
+	if i.Member != nil {
+		user = i.Member.User
+	}
+	// replace the DM's content and drop the buttons
+	update := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{Content: content, Components: []discordgo.MessageComponent{}},
+		})
+	}
+
+	pending, err := GetPendingProxyRSVP(id)
+	if err != nil {
+		update("This request is no longer pending.")
+		return
+	}
+	if user == nil || pending.UserID != user.ID {
+		return
+	}
+	ev, err := GetEventByID(pending.EventID)
+	if err != nil {
+		update("Could not find the event record.")
+		return
+	}
+	if err := DeletePendingProxyRSVP(pending.ID); err != nil {
+		log.Printf("Failed to remove pending proxy RSVP: %v", err)
+	}
+	if !confirm {
+		refreshEventMessage(s, ev)
+		update(fmt.Sprintf("Declined. Your RSVP for %s %s is unchanged.", ev.Emoji, ev.Title))
+		return
+	}
+	// the event may have changed since the request was made
+	if ev.Cancelled() {
+		refreshEventMessage(s, ev)
+		update("This event has been cancelled.")
+		return
+	}
+	if ev.RSVPClosed() && !canBypassRSVPDeadline(s, ev, pending.UserID) {
+		refreshEventMessage(s, ev)
+		update(rsvpClosedMessage(ev))
+		return
+	}
+	if msg := guestLimitError(ev, pending.Guests); msg != "" {
+		refreshEventMessage(s, ev)
+		update(msg + " The request was dropped; RSVP yourself instead.")
+		return
+	}
+	outcome, err := UpsertResponse(ev.ID, pending.UserID, pending.ActorID, pending.Response, pending.Guests)
+	if errors.Is(err, ErrNoRoomForGuests) {
+		refreshEventMessage(s, ev)
+		update(noRoomForGuestsMessage)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to persist confirmed proxy RSVP: %v", err)
+		update("Failed to save RSVP.")
+		return
+	}
+	refreshEventMessage(s, ev)
+	announcePromotions(s, ev.ChannelID, outcome.Promoted)
+	update(fmt.Sprintf("Confirmed. %s", rsvpConfirmation(fmt.Sprintf("<@%s>", pending.UserID), pending.Response, outcome)))
+}
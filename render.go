@@ -3,11 +3,17 @@ package main
 import (
     "bytes"
     "fmt"
+    "log"
     "text/template"
     "io/ioutil"
     "path/filepath"
+
+    "github.com/bwmarrin/discordgo"
 )
 
+// maxInlineComment is the longest RSVP comment shown next to the attendee.
+const maxInlineComment = 60
+
 // RenderEventMessage builds the event message text from the template and DB row.
 func RenderEventMessage(channelID string) (string, error) {
     ev, err := GetEventByChannel(channelID)
@@ -22,26 +28,163 @@ func RenderEventMessage(channelID string) (string, error) {
         // ignore errors and use empty lists
         goingIDs, maybeIDs, cantIDs = []string{}, []string{}, []string{}
     }
+    details, cerr := GetResponseDetails(ev.ID)
+    if cerr != nil {
+        details = map[string]ResponseDetail{}
+    }
+    // Short comments sit next to the attendee; long ones move to a Comments
+    // section at the bottom so the lists stay readable.
+    var longComments []string
+    entry := func(id string) string {
+        out := "<@" + id + ">"
+        d := details[id]
+        if d.Guests > 0 {
+            out += fmt.Sprintf(" (+%d)", d.Guests)
+        }
+        if d.Comment != "" {
+            if len([]rune(d.Comment)) > maxInlineComment {
+                longComments = append(longComments, fmt.Sprintf("<@%s>: %s", id, d.Comment))
+                out += " (see comments)"
+            } else {
+                out += " — " + d.Comment
+            }
+        }
+        return out
+    }
+    waitIDs, werr := GetWaitlistForEvent(ev.ID)
+    if werr != nil {
+        waitIDs = []string{}
+    }
+    goingCount := len(goingIDs)
+    goingGuests := 0
+    for _, id := range goingIDs {
+        goingGuests += details[id].Guests
+    }
+    goingCount += goingGuests
     mentions := func(ids []string) []string {
         out := make([]string, 0, len(ids))
         for _, id := range ids {
-            out = append(out, "<@"+id+">")
+            out = append(out, entry(id))
         }
         return out
     }
+    going, maybe, cant := mentions(goingIDs), mentions(maybeIDs), mentions(cantIDs)
+    maybeCount, cantCount := len(maybe), len(cant)
+    // Unconfirmed proxy RSVPs are listed but not counted until the user confirms.
+    pending, perr := GetPendingProxyRSVPsForEvent(ev.ID)
+    if perr != nil {
+        pending = nil
+    }
+    for _, p := range pending {
+        e := fmt.Sprintf("<@%s> (unconfirmed, via <@%s>)", p.UserID, p.ActorID)
+        switch p.Response {
+        case "yes":
+            going = append(going, e)
+        case "maybe":
+            maybe = append(maybe, e)
+        case "no":
+            cant = append(cant, e)
+        }
+    }
+    waitlist := make([]string, 0, len(waitIDs))
+    for n, id := range waitIDs {
+        waitlist = append(waitlist, fmt.Sprintf("%d. %s", n+1, entry(id)))
+    }
+    // Invited users who haven't answered (role invites can't be expanded here).
+    responded := map[string]bool{}
+    for _, ids := range [][]string{goingIDs, maybeIDs, cantIDs, waitIDs} {
+        for _, id := range ids {
+            responded[id] = true
+        }
+    }
+    for _, p := range pending {
+        responded[p.UserID] = true
+    }
+    noResponse := []string{}
+    if invites, ierr := GetInvites(ev.ID); ierr == nil {
+        for _, inv := range invites {
+            if inv.TargetType == "user" && !responded[inv.TargetID] {
+                noResponse = append(noResponse, "<@"+inv.TargetID+">")
+            }
+        }
+    }
+    // Co-hosts are listed after the author under "Organized by".
+    organizers := "<@" + ev.AuthorID + ">"
+    if cohosts, herr := GetCohosts(ev.ID); herr == nil {
+        for _, id := range cohosts {
+            organizers += ", <@" + id + ">"
+        }
+    }
     data := map[string]interface{}{
+        "Cancelled": ev.Cancelled(),
         "Emoji":     ev.Emoji,
         "Title":     ev.Title,
-        "Organizer": "<@" + ev.AuthorID + ">",
-    "Dates":     func() string { if ev.Date != nil { return fmt.Sprintf("<t:%d:R>", ev.Date.Unix()) }; return "TBD" }(),
+        "Organizer": organizers,
+    "Dates":     formatEventDates(ev),
         "Location":  ev.Location,
         "Price":     ev.Price,
-        "Going":     mentions(goingIDs),
-        "Maybe":     mentions(maybeIDs),
-        "CantMakeIt": mentions(cantIDs),
+        "Going":     going,
+        "GoingCount": goingCount,
+        "HasGuests": goingGuests > 0,
+        "Maybe":     maybe,
+        "MaybeCount": maybeCount,
+        "CantMakeIt": cant,
+        "CantCount": cantCount,
+        "NoResponse": noResponse,
+        "Comments":  longComments,
+        "Capacity":  ev.Capacity,
+        "Deadline":  formatDeadline(ev),
+        "Waitlist":  waitlist,
         "Notes":     func() []string { if ev.Description != "" { return []string{ev.Description} } ; return []string{} }(),
     }
+    return executeEventTemplate(data)
+}
+
+// RenderEventPreview renders an event that hasn't been created yet, exactly
+// as it would first be posted: no RSVPs, with invited users listed as not
+// having responded.
+func RenderEventPreview(ev *Event, invitees []EventInvite, cohosts []string) (string, error) {
+    organizers := "<@" + ev.AuthorID + ">"
+    for _, id := range cohosts {
+        organizers += ", <@" + id + ">"
+    }
+    noResponse := []string{}
+    for _, inv := range invitees {
+        if inv.TargetType == "user" {
+            noResponse = append(noResponse, "<@"+inv.TargetID+">")
+        }
+    }
+    notes := []string{}
+    if ev.Description != "" {
+        notes = append(notes, ev.Description)
+    }
+    data := map[string]interface{}{
+        "Cancelled":  false,
+        "Emoji":      ev.Emoji,
+        "Title":      ev.Title,
+        "Organizer":  organizers,
+        "Dates":      formatEventDates(ev),
+        "Location":   ev.Location,
+        "Price":      ev.Price,
+        "Going":      []string{},
+        "GoingCount": 0,
+        "HasGuests":  false,
+        "Maybe":      []string{},
+        "MaybeCount": 0,
+        "CantMakeIt": []string{},
+        "CantCount":  0,
+        "NoResponse": noResponse,
+        "Comments":   []string{},
+        "Capacity":   ev.Capacity,
+        "Deadline":   formatDeadline(ev),
+        "Waitlist":   []string{},
+        "Notes":      notes,
+    }
+    return executeEventTemplate(data)
+}
 
+// executeEventTemplate fills event.tmpl with data.
+func executeEventTemplate(data map[string]interface{}) (string, error) {
     tmplPath := filepath.Join(".", "event.tmpl")
     b, err := ioutil.ReadFile(tmplPath)
     if err != nil {
@@ -57,3 +200,46 @@ func RenderEventMessage(channelID string) (string, error) {
     }
     return buf.String(), nil
 }
+
+// formatEventDates renders the start (and end, when set) as Discord timestamps.
+// Events without an end keep the short relative form; same-day events only
+// repeat the end time, multi-day events show both full dates.
+func formatEventDates(ev *Event) string {
+    if ev.Date == nil {
+        return "TBD"
+    }
+    if ev.EndDate == nil {
+        return fmt.Sprintf("<t:%d:R>", ev.Date.Unix())
+    }
+    loc := defaultLocation()
+    sy, sm, sd := ev.Date.In(loc).Date()
+    ey, em, ed := ev.EndDate.In(loc).Date()
+    endStyle := "f"
+    if sy == ey && sm == em && sd == ed {
+        endStyle = "t"
+    }
+    return fmt.Sprintf("<t:%d:f> – <t:%d:%s> (<t:%d:R>)", ev.Date.Unix(), ev.EndDate.Unix(), endStyle, ev.Date.Unix())
+}
+
+// formatDeadline renders the RSVP cut-off, or "" when the event has none.
+func formatDeadline(ev *Event) string {
+    if ev.Deadline == nil {
+        return ""
+    }
+    if ev.RSVPClosed() {
+        return fmt.Sprintf("<t:%d:f> (closed)", ev.Deadline.Unix())
+    }
+    return fmt.Sprintf("<t:%d:f> (<t:%d:R>)", ev.Deadline.Unix(), ev.Deadline.Unix())
+}
+
+// refreshEventMessage re-renders the event's posted message.
+func refreshEventMessage(s *discordgo.Session, ev *Event) {
+    if ev.MessageID == "" {
+        return
+    }
+    if rendered, rerr := RenderEventMessage(ev.ChannelID); rerr == nil {
+        if _, err := s.ChannelMessageEdit(ev.ChannelID, ev.MessageID, rendered); err != nil {
+            log.Printf("Failed to update event message: %v", err)
+        }
+    }
+}
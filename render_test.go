@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestFormatEventDates(t *testing.T) {
+	at := func(s string) *time.Time {
+		v, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &v
+	}
+	start := at("2025-05-02T21:00:00Z")
+	tests := []struct {
+		name string
+		zone string
+		end  *time.Time
+		want string
+	}{
+		{name: "no end", zone: "America/Chicago", want: fmt.Sprintf("<t:%d:R>", start.Unix())},
+		// 16:00 to 18:00 in Chicago, but 23:00 to 01:00 in Berlin
+		{name: "same day", zone: "America/Chicago", end: at("2025-05-02T23:00:00Z"), want: "t"},
+		{name: "past midnight in the event zone", zone: "Europe/Berlin", end: at("2025-05-02T23:00:00Z"), want: "f"},
+		{name: "several days", zone: "America/Chicago", end: at("2025-05-04T23:00:00Z"), want: "f"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EVENT_TIMEZONE", tt.zone)
+			if _, err := time.LoadLocation(tt.zone); err != nil {
+				t.Skipf("timezone data unavailable: %v", err)
+			}
+			want := tt.want
+			if tt.end != nil {
+				want = fmt.Sprintf("<t:%d:f> – <t:%d:%s> (<t:%d:R>)", start.Unix(), tt.end.Unix(), tt.want, start.Unix())
+			}
+			if got := formatEventDates(&Event{Date: start, EndDate: tt.end}); got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+	if got := formatEventDates(&Event{}); got != "TBD" {
+		t.Errorf("undated event: got %q, want TBD", got)
+	}
+}
+
+func TestFormatDeadline(t *testing.T) {
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	future := time.Now().Add(time.Hour).Truncate(time.Second)
+	tests := []struct {
+		name     string
+		deadline *time.Time
+		want     string
+	}{
+		{name: "none", want: ""},
+		{name: "open", deadline: &future, want: fmt.Sprintf("<t:%d:f> (<t:%d:R>)", future.Unix(), future.Unix())},
+		{name: "closed", deadline: &past, want: fmt.Sprintf("<t:%d:f> (closed)", past.Unix())},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatDeadline(&Event{Deadline: tt.deadline}); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,14 +1,22 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/bwmarrin/discordgo"
 )
 
+// minGuests is the smallest plus-one count accepted by the guests options.
+var minGuests = 0.0
+
+// maxRSVPComment caps the length of a note attached to a response.
+const maxRSVPComment = 200
+
 func registerRSVP(s *discordgo.Session, guildID string) {
 	cmd := &discordgo.ApplicationCommand{
 		Name:        "rsvp",
@@ -26,6 +34,20 @@ func registerRSVP(s *discordgo.Session, guildID string) {
 				Description: "Optional: The user to RSVP for",
 				Required:    false,
 			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "guests",
+				Description: "Optional: How many plus-ones you're bringing",
+				Required:    false,
+				MinValue:    &minGuests,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "comment",
+				Description: "Optional: A note like 'arriving late' ('clear' removes it)",
+				Required:    false,
+				MaxLength:   maxRSVPComment,
+			},
 		},
 	}
 	_, err := s.ApplicationCommandCreate(s.State.User.ID, guildID, cmd)
@@ -41,13 +63,18 @@ func handleRSVPCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	if i.ApplicationCommandData().Name != "rsvp" {
 		return
 	}
-	var response, userID string
+	var response, userID, comment string
+	guests := -1 // keep the current count unless given
 	for _, opt := range i.ApplicationCommandData().Options {
 		switch opt.Name {
 		case "response":
 			response = strings.ToLower(opt.StringValue())
 		case "user":
 			userID = opt.UserValue(nil).ID
+		case "guests":
+			guests = int(opt.IntValue())
+		case "comment":
+			comment = strings.TrimSpace(opt.StringValue())
 		}
 	}
 	if response != "yes" && response != "no" && response != "maybe" {
@@ -74,14 +101,57 @@ func handleRSVPCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		})
 		return
 	}
-	if err := UpsertResponse(ev.ID, userID, response); err != nil {
-		log.Printf("Failed to persist RSVP: %v", err)
+	if ev.Cancelled() {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "This event has been cancelled.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	if ev.RSVPClosed() && !canBypassRSVPDeadline(s, ev, i.Member.User.ID) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: rsvpClosedMessage(ev), Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	if msg := guestLimitError(ev, guests); msg != "" {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{Content: "Failed to save RSVP.", Flags: discordgo.MessageFlagsEphemeral},
+			Data: &discordgo.InteractionResponseData{Content: msg, Flags: discordgo.MessageFlagsEphemeral},
 		})
 		return
 	}
+	if refusal, needsConfirm := proxyRSVPRefusal(ev, i.GuildID, i.Member.User.ID, userID); refusal != "" || needsConfirm {
+		msg := refusal
+		if needsConfirm {
+			msg = requestProxyConfirmation(s, ev, userID, i.Member.User.ID, response, guests)
+		}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: msg, Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	outcome, err := UpsertResponse(ev.ID, userID, i.Member.User.ID, response, guests)
+	if err != nil {
+		msg := "Failed to save RSVP."
+		if errors.Is(err, ErrNoRoomForGuests) {
+			msg = noRoomForGuestsMessage
+		} else {
+			log.Printf("Failed to persist RSVP: %v", err)
+		}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: msg, Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	if comment != "" {
+		if _, err := SetResponseComment(ev.ID, userID, commentValue(comment)); err != nil {
+			log.Printf("Failed to save RSVP comment: %v", err)
+		}
+	}
 
 	// Re-render message and edit
 	if ev.MessageID != "" {
@@ -92,18 +162,21 @@ func handleRSVPCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		}
 	}
 
+	announcePromotions(s, i.ChannelID, outcome.Promoted)
+
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: fmt.Sprintf("RSVP updated for %s: %s", userMention, response),
+			Content: rsvpConfirmation(userMention, response, outcome),
 			Flags:   discordgo.MessageFlagsEphemeral,
 		},
 	})
 }
 
 // handleRSVPMessage parses plain-text messages that start with /rsvp and
-// supports the syntax: /rsvp (yes|no|maybe) (@user optional)
+// supports the syntax: /rsvp (yes|no|maybe) (@user optional) (+N guests optional) (-- comment optional)
 // Mentions in message content are like <@715414244270538754> or <@!7154...>
+// Only text after "--" is a comment, so a stray word can't become one.
 func handleRSVPMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
 	if m.Author == nil || m.Author.ID == s.State.User.ID {
 		return
@@ -112,9 +185,12 @@ func handleRSVPMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
 	if !strings.HasPrefix(content, "/rsvp") {
 		return
 	}
-	parts := strings.Fields(content)
+	const usage = "Usage: /rsvp (yes/no/maybe) (@user optional) (+guests optional) (-- comment optional)"
+	head, comment, _ := strings.Cut(content, "--")
+	comment = strings.TrimSpace(comment)
+	parts := strings.Fields(head)
 	if len(parts) < 2 {
-		_, _ = s.ChannelMessageSend(m.ChannelID, "Usage: /rsvp (yes/no/maybe) (@user optional)")
+		_, _ = s.ChannelMessageSend(m.ChannelID, usage)
 		return
 	}
 	response := strings.ToLower(parts[1])
@@ -126,16 +202,33 @@ func handleRSVPMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
 	// default to the message author
 	userID := m.Author.ID
 
-	// If the message has explicit mentions parsed by Discord, prefer that
-	if m.Mentions != nil && len(m.Mentions) > 0 {
-		userID = m.Mentions[0].ID
-	} else if len(parts) >= 3 {
-		// Try to parse a raw mention like <@123456789> or <@!123456789>
-		re := regexp.MustCompile(`^<@!?(\d+)>$`)
-		if sub := re.FindStringSubmatch(parts[2]); len(sub) == 2 {
+	// A mention right after the response names who the RSVP is for; mentions
+	// in the comment don't count.
+	mentionRe := regexp.MustCompile(`^<@!?(\d+)>$`)
+	rest := parts[2:]
+	if len(rest) > 0 {
+		if sub := mentionRe.FindStringSubmatch(rest[0]); len(sub) == 2 {
 			userID = sub[1]
+			rest = rest[1:]
+		}
+	}
+
+	// Optional guest count like "+2"; anything else before "--" is a mistake
+	guests := -1
+	guestRe := regexp.MustCompile(`^\+(\d+)$`)
+	if len(rest) > 0 {
+		if sub := guestRe.FindStringSubmatch(rest[0]); len(sub) == 2 {
+			guests, _ = strconv.Atoi(sub[1])
+			rest = rest[1:]
 		}
 	}
+	if len(rest) > 0 {
+		_, _ = s.ChannelMessageSend(m.ChannelID, usage+". Put a comment after --, like `/rsvp yes -- arriving late`.")
+		return
+	}
+	if len([]rune(comment)) > maxRSVPComment {
+		comment = string([]rune(comment)[:maxRSVPComment])
+	}
 
 	userMention := fmt.Sprintf("<@%s>", userID)
 
@@ -144,11 +237,40 @@ func handleRSVPMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
 		_, _ = s.ChannelMessageSend(m.ChannelID, "Could not find the event record.")
 		return
 	}
-	if err := UpsertResponse(ev.ID, userID, response); err != nil {
+	if ev.Cancelled() {
+		_, _ = s.ChannelMessageSend(m.ChannelID, "This event has been cancelled.")
+		return
+	}
+	if ev.RSVPClosed() && !canBypassRSVPDeadline(s, ev, m.Author.ID) {
+		_, _ = s.ChannelMessageSend(m.ChannelID, rsvpClosedMessage(ev))
+		return
+	}
+	if msg := guestLimitError(ev, guests); msg != "" {
+		_, _ = s.ChannelMessageSend(m.ChannelID, msg)
+		return
+	}
+	if refusal, needsConfirm := proxyRSVPRefusal(ev, m.GuildID, m.Author.ID, userID); refusal != "" {
+		_, _ = s.ChannelMessageSend(m.ChannelID, refusal)
+		return
+	} else if needsConfirm {
+		_, _ = s.ChannelMessageSend(m.ChannelID, requestProxyConfirmation(s, ev, userID, m.Author.ID, response, guests))
+		return
+	}
+	outcome, err := UpsertResponse(ev.ID, userID, m.Author.ID, response, guests)
+	if errors.Is(err, ErrNoRoomForGuests) {
+		_, _ = s.ChannelMessageSend(m.ChannelID, noRoomForGuestsMessage)
+		return
+	}
+	if err != nil {
 		log.Printf("Failed to persist RSVP (message): %v", err)
 		_, _ = s.ChannelMessageSend(m.ChannelID, "Failed to save RSVP.")
 		return
 	}
+	if comment != "" {
+		if _, err := SetResponseComment(ev.ID, userID, commentValue(comment)); err != nil {
+			log.Printf("Failed to save RSVP comment (message): %v", err)
+		}
+	}
 
 	// Re-render and edit the event message if present
 	if ev.MessageID != "" {
@@ -159,7 +281,59 @@ func handleRSVPMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
 		}
 	}
 
-	_, _ = s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("RSVP updated for %s: %s", userMention, response))
+	announcePromotions(s, m.ChannelID, outcome.Promoted)
+
+	_, _ = s.ChannelMessageSend(m.ChannelID, rsvpConfirmation(userMention, response, outcome))
+}
+
+// commentValue maps the "clear" keyword to an empty comment.
+func commentValue(comment string) string {
+	if strings.EqualFold(comment, "clear") {
+		return ""
+	}
+	return comment
+}
+
+// noRoomForGuestsMessage answers a confirmed attendee whose extra plus-ones
+// don't fit.
+const noRoomForGuestsMessage = "There isn't room for that many plus-ones; your spot and current plus-ones are unchanged."
+
+// rsvpClosedMessage explains to a non-organizer why their change was refused.
+func rsvpClosedMessage(ev *Event) string {
+	return fmt.Sprintf("RSVPs for this event closed <t:%d:R>. Ask the organizer if you need to change your response.", ev.Deadline.Unix())
+}
+
+// guestLimitError returns a user-facing message when guests exceeds the
+// event's plus-one cap, or "" when the count is allowed.
+func guestLimitError(ev *Event, guests int) string {
+	if ev.MaxGuests < 0 || guests <= ev.MaxGuests {
+		return ""
+	}
+	if ev.MaxGuests == 0 {
+		return "Plus-ones aren't allowed for this event."
+	}
+	return fmt.Sprintf("This event allows at most %d plus-ones per person.", ev.MaxGuests)
+}
+
+// rsvpConfirmation is the reply shown after saving a response.
+func rsvpConfirmation(userMention, response string, outcome *RSVPOutcome) string {
+	msg := fmt.Sprintf("RSVP updated for %s: %s", userMention, response)
+	if outcome != nil && outcome.Waitlisted {
+		msg = fmt.Sprintf("The event is full, so %s is on the waitlist.", userMention)
+	}
+	if outcome != nil && outcome.GuestsCapped {
+		msg += fmt.Sprintf(" Plus-ones were lowered to the event's limit of %d.", outcome.Guests)
+	}
+	return msg
+}
+
+// announcePromotions tells users in the event channel they got a seat.
+func announcePromotions(s *discordgo.Session, channelID string, promoted []string) {
+	for _, id := range promoted {
+		if _, err := s.ChannelMessageSend(channelID, fmt.Sprintf("<@%s> a spot opened up, you're off the waitlist and now going!", id)); err != nil {
+			log.Printf("Failed to announce waitlist promotion: %v", err)
+		}
+	}
 }
 
 // Helper to update RSVP section in message
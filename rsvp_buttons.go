@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// RSVP button custom IDs look like "rsvp:<event id>:<yes|maybe|no>". Binding
+// them to the event ID rather than the channel keeps them working wherever the
+// message ends up.
+const rsvpButtonPrefix = "rsvp:"
+
+// The "Note" button ("rsvpnote:<event id>") opens a modal whose submit comes
+// back as "rsvp_comment_modal:<event id>".
+const (
+	rsvpNoteButtonPrefix   = "rsvpnote:"
+	rsvpCommentModalPrefix = "rsvp_comment_modal:"
+)
+
+// rsvpButtons builds the Going / Maybe / Can't / Note / Add to calendar row
+// attached to event messages.
+func rsvpButtons(eventID int64) []discordgo.MessageComponent {
+	id := func(resp string) string {
+		return fmt.Sprintf("%s%d:%s", rsvpButtonPrefix, eventID, resp)
+	}
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Going", Style: discordgo.SuccessButton, CustomID: id("yes"), Emoji: &discordgo.ComponentEmoji{Name: "✅"}},
+			discordgo.Button{Label: "Maybe", Style: discordgo.SecondaryButton, CustomID: id("maybe"), Emoji: &discordgo.ComponentEmoji{Name: "❓"}},
+			discordgo.Button{Label: "Can't", Style: discordgo.DangerButton, CustomID: id("no"), Emoji: &discordgo.ComponentEmoji{Name: "❌"}},
+			discordgo.Button{Label: "Note", Style: discordgo.SecondaryButton, CustomID: fmt.Sprintf("%s%d", rsvpNoteButtonPrefix, eventID), Emoji: &discordgo.ComponentEmoji{Name: "💬"}},
+			discordgo.Button{Label: "Add to calendar", Style: discordgo.SecondaryButton, CustomID: fmt.Sprintf("%s%d", icsButtonPrefix, eventID), Emoji: &discordgo.ComponentEmoji{Name: "📅"}},
+		}},
+	}
+}
+
+// parseRSVPButtonID splits an RSVP button custom ID into event ID and response.
+func parseRSVPButtonID(customID string) (int64, string, bool) {
+	if !strings.HasPrefix(customID, rsvpButtonPrefix) {
+		return 0, "", false
+	}
+	parts := strings.Split(strings.TrimPrefix(customID, rsvpButtonPrefix), ":")
+	if len(parts) != 2 {
+		return 0, "", false
+	}
+	eventID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	switch parts[1] {
+	case "yes", "maybe", "no":
+		return eventID, parts[1], true
+	}
+	return 0, "", false
+}
+
+func handleRSVPButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionMessageComponent {
+		return
+	}
+	eventID, response, ok := parseRSVPButtonID(i.MessageComponentData().CustomID)
+	if !ok {
+		return
+	}
+	// outside a guild the user isn't wrapped in a Member
+	var userID string
+	if i.Member != nil {
+		userID = i.Member.User.ID
+	} else if i.User != nil {
+		userID = i.User.ID
+	} else {
+		return
+	}
+
+	ev, err := GetEventByID(eventID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "Could not find the event record.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	if ev.Cancelled() {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "This event has been cancelled.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	if ev.RSVPClosed() && !canBypassRSVPDeadline(s, ev, userID) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: rsvpClosedMessage(ev), Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	outcome, err := UpsertResponse(ev.ID, userID, userID, response, -1)
+	if err != nil {
+		log.Printf("Failed to persist RSVP (button): %v", err)
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "Failed to save RSVP.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+
+	// Re-render the event's own message and, if the button was clicked on a
+	// different copy (e.g. a repost), that one too.
+	if rendered, rerr := RenderEventMessage(ev.ChannelID); rerr == nil {
+		if ev.MessageID != "" {
+			if _, err := s.ChannelMessageEdit(ev.ChannelID, ev.MessageID, rendered); err != nil {
+				log.Printf("Failed to update RSVP message (button): %v", err)
+			}
+		}
+		if i.Message != nil && i.Message.ID != ev.MessageID {
+			if _, err := s.ChannelMessageEdit(i.ChannelID, i.Message.ID, rendered); err != nil {
+				log.Printf("Failed to update RSVP message copy (button): %v", err)
+			}
+		}
+	}
+	announcePromotions(s, ev.ChannelID, outcome.Promoted)
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: rsvpConfirmation(fmt.Sprintf("<@%s>", userID), response, outcome),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleRSVPCommentModal opens the comment modal from the Note button and
+// saves its submission. An empty submission clears the comment.
+func handleRSVPCommentModal(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// outside a guild the user isn't wrapped in a Member
+	var userID string
+	if i.Member != nil {
+		userID = i.Member.User.ID
+	} else if i.User != nil {
+		userID = i.User.ID
+	} else {
+		return
+	}
+
+	if i.Type == discordgo.InteractionMessageComponent {
+		customID := i.MessageComponentData().CustomID
+		if !strings.HasPrefix(customID, rsvpNoteButtonPrefix) {
+			return
+		}
+		eventID, err := strconv.ParseInt(strings.TrimPrefix(customID, rsvpNoteButtonPrefix), 10, 64)
+		if err != nil {
+			return
+		}
+		// a note is part of the response, so it closes with the RSVPs
+		if ev, err := GetEventByID(eventID); err == nil && ev.Cancelled() {
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{Content: "This event has been cancelled.", Flags: discordgo.MessageFlagsEphemeral},
+			})
+			return
+		} else if err == nil && ev.RSVPClosed() && !canBypassRSVPDeadline(s, ev, userID) {
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{Content: rsvpClosedMessage(ev), Flags: discordgo.MessageFlagsEphemeral},
+			})
+			return
+		}
+		// prefill with the current comment so it can be edited
+		current, _ := GetResponseComment(eventID, userID)
+		modal := &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseModal,
+			Data: &discordgo.InteractionResponseData{
+				CustomID: fmt.Sprintf("%s%d", rsvpCommentModalPrefix, eventID),
+				Title:    "RSVP note",
+				Components: []discordgo.MessageComponent{
+					&discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+						&discordgo.TextInput{
+							CustomID:    "comment_input",
+							Label:       "Note (leave empty to clear)",
+							Style:       discordgo.TextInputShort,
+							Required:    false,
+							Placeholder: "Arriving late, bringing dessert...",
+							Value:       current,
+							MaxLength:   maxRSVPComment,
+						},
+					}},
+				},
+			},
+		}
+		if err := s.InteractionRespond(i.Interaction, modal); err != nil {
+			log.Printf("failed to open RSVP note modal: %v", err)
+		}
+		return
+	}
+
+	if i.Type != discordgo.InteractionModalSubmit {
+		return
+	}
+	customID := i.ModalSubmitData().CustomID
+	if !strings.HasPrefix(customID, rsvpCommentModalPrefix) {
+		return
+	}
+	eventID, err := strconv.ParseInt(strings.TrimPrefix(customID, rsvpCommentModalPrefix), 10, 64)
+	if err != nil {
+		return
+	}
+	var comment string
+	for _, row := range i.ModalSubmitData().Components {
+		if ar, ok := row.(*discordgo.ActionsRow); ok {
+			for _, comp := range ar.Components {
+				if ti, ok := comp.(*discordgo.TextInput); ok && ti.CustomID == "comment_input" {
+					comment = strings.TrimSpace(ti.Value)
+				}
+			}
+		}
+	}
+
+	ev, err := GetEventByID(eventID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "Could not find the event record.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	if ev.Cancelled() {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "This event has been cancelled.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	// the deadline may have passed while the modal was open
+	if ev.RSVPClosed() && !canBypassRSVPDeadline(s, ev, userID) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: rsvpClosedMessage(ev), Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	found, err := SetResponseComment(ev.ID, userID, comment)
+	if err != nil {
+		log.Printf("Failed to save RSVP comment (modal): %v", err)
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "Failed to save your note.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	if !found {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "RSVP first, then add a note.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	refreshEventMessage(s, ev)
+
+	msg := "Note saved."
+	if comment == "" {
+		msg = "Note cleared."
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: msg, Flags: discordgo.MessageFlagsEphemeral},
+	})
+}
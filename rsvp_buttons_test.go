@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestParseRSVPButtonID(t *testing.T) {
+	tests := []struct {
+		customID string
+		eventID  int64
+		response string
+		ok       bool
+	}{
+		{customID: rsvpButtonPrefix + "42:yes", eventID: 42, response: "yes", ok: true},
+		{customID: rsvpButtonPrefix + "42:maybe", eventID: 42, response: "maybe", ok: true},
+		{customID: rsvpButtonPrefix + "42:no", eventID: 42, response: "no", ok: true},
+		{customID: rsvpButtonPrefix + "42:perhaps"},
+		{customID: rsvpButtonPrefix + "abc:yes"},
+		{customID: rsvpButtonPrefix + "42"},
+		{customID: rsvpButtonPrefix + "42:yes:extra"},
+		{customID: rsvpNoteButtonPrefix + "42"},
+		{customID: "42:yes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.customID, func(t *testing.T) {
+			eventID, response, ok := parseRSVPButtonID(tt.customID)
+			if eventID != tt.eventID || response != tt.response || ok != tt.ok {
+				t.Errorf("parseRSVPButtonID(%q) = %d, %q, %v; want %d, %q, %v", tt.customID, eventID, response, ok, tt.eventID, tt.response, tt.ok)
+			}
+		})
+	}
+}
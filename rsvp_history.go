@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// historyTimelineLimit and historyDaysLimit cap how many of the most recent
+// changes and days /rsvp_history lists, keeping the reply under Discord's
+// message size limit.
+const (
+	historyTimelineLimit = 15
+	historyDaysLimit     = 10
+)
+
+func registerRSVPHistory(s *discordgo.Session, guildID string) {
+	cmd := &discordgo.ApplicationCommand{
+		Name:        "rsvp_history",
+		Description: "Organizer only: show the RSVP change timeline for the event in the current channel",
+	}
+	_, err := s.ApplicationCommandCreate(s.State.User.ID, guildID, cmd)
+	if err != nil {
+		log.Printf("Cannot create '/rsvp_history' command: %v", err)
+	}
+}
+
+func handleRSVPHistoryCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	if i.ApplicationCommandData().Name != "rsvp_history" {
+		return
+	}
+	ev := requireEventManager(s, i)
+	if ev == nil {
+		return
+	}
+	history, err := GetResponseHistory(ev.ID)
+	if err != nil {
+		log.Printf("Failed to load RSVP history: %v", err)
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "Failed to load RSVP history.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:         formatRSVPHistory(ev, history),
+			Flags:           discordgo.MessageFlagsEphemeral,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+// formatRSVPHistory renders the change timeline followed by a per-day
+// headcount (going incl. guests, not counting the waitlist, at the end of each
+// day with changes).
+func formatRSVPHistory(ev *Event, history []ResponseChange) string {
+	if len(history) == 0 {
+		return fmt.Sprintf("No RSVP changes recorded for %s yet.", ev.Title)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "**RSVP history for %s** (%d changes)\n", ev.Title, len(history))
+
+	shown := history
+	if len(shown) > historyTimelineLimit {
+		shown = shown[len(shown)-historyTimelineLimit:]
+		fmt.Fprintf(&b, "_Showing the latest %d._\n", historyTimelineLimit)
+	}
+	describe := func(resp string, guests int, waitlisted bool) string {
+		if resp == "" {
+			return "none"
+		}
+		if guests > 0 {
+			resp = fmt.Sprintf("%s (+%d)", resp, guests)
+		}
+		if waitlisted {
+			resp += " (waitlisted)"
+		}
+		return resp
+	}
+	for _, c := range shown {
+		line := fmt.Sprintf("<t:%d:f> <@%s>: %s → %s", c.ChangedAt.Unix(), c.UserID,
+			describe(c.OldResponse, c.OldGuests, c.OldWaitlisted), describe(c.NewResponse, c.NewGuests, c.NewWaitlisted))
+		if c.ActorID != c.UserID {
+			line += fmt.Sprintf(" (by <@%s>)", c.ActorID)
+		}
+		b.WriteString(line + "\n")
+	}
+
+	// Replay the full history to get the headcount at the end of each day.
+	b.WriteString("\n**Headcount over time**\n")
+	loc := defaultLocation()
+	going := map[string]int{} // user id -> party size while going
+	total := 0
+	var day string
+	var dayEnd time.Time
+	var days []string
+	flush := func() {
+		if day != "" {
+			days = append(days, fmt.Sprintf("<t:%d:d>: %d going\n", dayEnd.Unix(), total))
+		}
+	}
+	for _, c := range history {
+		d := c.ChangedAt.In(loc).Format("2006-01-02")
+		if d != day {
+			flush()
+			day = d
+		}
+		dayEnd = c.ChangedAt
+		total -= going[c.UserID]
+		delete(going, c.UserID)
+		// a waitlisted yes doesn't have a seat yet
+		if c.NewResponse == "yes" && !c.NewWaitlisted {
+			going[c.UserID] = 1 + c.NewGuests
+			total += going[c.UserID]
+		}
+	}
+	flush()
+	if len(days) > historyDaysLimit {
+		days = days[len(days)-historyDaysLimit:]
+	}
+	b.WriteString(strings.Join(days, ""))
+	return b.String()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatRSVPHistoryHeadcount(t *testing.T) {
+	day := time.Date(2025, 5, 1, 18, 0, 0, 0, time.UTC)
+	ev := &Event{Title: "Poker"}
+	tests := []struct {
+		name    string
+		history []ResponseChange
+		want    string
+	}{
+		{
+			name: "guests count as going",
+			history: []ResponseChange{
+				{UserID: "a", ActorID: "a", NewResponse: "yes", NewGuests: 2, ChangedAt: day},
+			},
+			want: ": 3 going",
+		},
+		{
+			name: "waitlisted yes is left out",
+			history: []ResponseChange{
+				{UserID: "a", ActorID: "a", NewResponse: "yes", ChangedAt: day},
+				{UserID: "b", ActorID: "b", NewResponse: "yes", NewWaitlisted: true, ChangedAt: day.Add(time.Minute)},
+			},
+			want: ": 1 going",
+		},
+		{
+			name: "promotion adds the party",
+			history: []ResponseChange{
+				{UserID: "a", ActorID: "a", NewResponse: "yes", ChangedAt: day},
+				{UserID: "b", ActorID: "b", NewResponse: "yes", NewGuests: 1, NewWaitlisted: true, ChangedAt: day.Add(time.Minute)},
+				{UserID: "a", ActorID: "a", OldResponse: "yes", NewResponse: "no", ChangedAt: day.Add(2 * time.Minute)},
+				{UserID: "b", ActorID: "b", OldResponse: "yes", NewResponse: "yes", OldGuests: 1, NewGuests: 1, OldWaitlisted: true, ChangedAt: day.Add(2 * time.Minute)},
+			},
+			want: ": 2 going",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatRSVPHistory(ev, tt.history)
+			_, headcount, _ := strings.Cut(got, "**Headcount over time**\n")
+			if !strings.Contains(headcount, tt.want) {
+				t.Errorf("headcount %q doesn't contain %q", headcount, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// reactionResponses maps the reactions seeded on an event message to RSVP
+// responses. reactionOrder is the order they're seeded in.
+var (
+	reactionResponses = map[string]string{"✅": "yes", "❓": "maybe", "❌": "no"}
+	reactionOrder     = []string{"✅", "❓", "❌"}
+)
+
+// seedRSVPReactions adds the RSVP reactions to an event message so members
+// only have to click them.
+func seedRSVPReactions(s *discordgo.Session, channelID, messageID string) {
+	for _, emoji := range reactionOrder {
+		if err := s.MessageReactionAdd(channelID, messageID, emoji); err != nil {
+			log.Printf("Failed to seed RSVP reaction %s: %v", emoji, err)
+		}
+	}
+}
+
+// removeOtherRSVPReactions drops the user's RSVP reactions other than keep so
+// each user has at most one choice showing.
+func removeOtherRSVPReactions(s *discordgo.Session, channelID, messageID, userID, keep string) {
+	for _, emoji := range reactionOrder {
+		if emoji == keep {
+			continue
+		}
+		if err := s.MessageReactionRemove(channelID, messageID, emoji, userID); err != nil {
+			log.Printf("Failed to remove RSVP reaction %s: %v", emoji, err)
+		}
+	}
+}
+
+func onMessageReactionAdd(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+	if r.UserID == s.State.User.ID {
+		return
+	}
+	response, ok := reactionResponses[r.Emoji.Name]
+	if !ok {
+		return
+	}
+	ev, err := GetEventByMessage(r.MessageID)
+	if err != nil || !ev.ReactionRSVP {
+		return
+	}
+	if ev.Cancelled() || (ev.RSVPClosed() && !canBypassRSVPDeadline(s, ev, r.UserID)) {
+		// undo the reaction so the message doesn't show a choice we didn't record
+		_ = s.MessageReactionRemove(r.ChannelID, r.MessageID, r.Emoji.Name, r.UserID)
+		return
+	}
+	outcome, err := UpsertResponse(ev.ID, r.UserID, r.UserID, response, -1)
+	if err != nil {
+		log.Printf("Failed to persist RSVP (reaction): %v", err)
+		return
+	}
+	// the response is saved first, so the removals below are ignored by
+	// onMessageReactionRemove as they no longer match the stored answer
+	removeOtherRSVPReactions(s, r.ChannelID, r.MessageID, r.UserID, r.Emoji.Name)
+	refreshEventMessage(s, ev)
+	announcePromotions(s, ev.ChannelID, outcome.Promoted)
+	if outcome.Waitlisted {
+		_, _ = s.ChannelMessageSend(ev.ChannelID, rsvpConfirmation(fmt.Sprintf("<@%s>", r.UserID), response, outcome))
+	}
+}
+
+func onMessageReactionRemove(s *discordgo.Session, r *discordgo.MessageReactionRemove) {
+	if r.UserID == s.State.User.ID {
+		return
+	}
+	response, ok := reactionResponses[r.Emoji.Name]
+	if !ok {
+		return
+	}
+	ev, err := GetEventByMessage(r.MessageID)
+	if err != nil || !ev.ReactionRSVP {
+		return
+	}
+	if ev.Cancelled() || (ev.RSVPClosed() && !canBypassRSVPDeadline(s, ev, r.UserID)) {
+		return
+	}
+	// Only a removal of the reaction matching the stored answer retracts it.
+	current, err := GetUserResponse(ev.ID, r.UserID)
+	if err != nil || current != response {
+		return
+	}
+	promoted, err := DeleteResponse(ev.ID, r.UserID, r.UserID)
+	if err != nil {
+		log.Printf("Failed to remove RSVP (reaction): %v", err)
+		return
+	}
+	refreshEventMessage(s, ev)
+	announcePromotions(s, ev.ChannelID, promoted)
+}
+
+// reconcileReactionRSVPs catches up on reactions made while the bot was
+// offline. It runs on every Ready, reconnects included, so a stored answer
+// always wins: it may be newer than the reaction, given through /rsvp or the
+// buttons. Users with a stored answer just have reactions that disagree with
+// it removed; users without one get the first of their reactions in seeding
+// order recorded, and the rest removed.
+func reconcileReactionRSVPs(s *discordgo.Session) {
+	events, err := ListReactionRSVPEvents()
+	if err != nil {
+		log.Printf("Failed to list reaction RSVP events: %v", err)
+		return
+	}
+	for _, ev := range events {
+		reacted := map[string][]string{} // user id -> emojis in seeding order
+		var users []string
+		for _, emoji := range reactionOrder {
+			after := ""
+			for {
+				page, err := s.MessageReactions(ev.ChannelID, ev.MessageID, emoji, 100, "", after)
+				if err != nil {
+					log.Printf("Failed to fetch %s reactions for event %d: %v", emoji, ev.ID, err)
+					break
+				}
+				for _, u := range page {
+					if u.ID == s.State.User.ID {
+						continue
+					}
+					if _, seen := reacted[u.ID]; !seen {
+						users = append(users, u.ID)
+					}
+					reacted[u.ID] = append(reacted[u.ID], emoji)
+				}
+				if len(page) < 100 {
+					break
+				}
+				after = page[len(page)-1].ID
+			}
+		}
+
+		changed := false
+		for _, userID := range users {
+			current, err := GetUserResponse(ev.ID, userID)
+			if err != nil {
+				continue
+			}
+			if current != "" {
+				keep := ""
+				for _, emoji := range reacted[userID] {
+					if reactionResponses[emoji] == current {
+						keep = emoji
+					}
+				}
+				if keep == "" || len(reacted[userID]) > 1 {
+					removeOtherRSVPReactions(s, ev.ChannelID, ev.MessageID, userID, keep)
+				}
+				continue
+			}
+			choice := reacted[userID][0]
+			if len(reacted[userID]) > 1 {
+				removeOtherRSVPReactions(s, ev.ChannelID, ev.MessageID, userID, choice)
+			}
+			if ev.Cancelled() || (ev.RSVPClosed() && !canBypassRSVPDeadline(s, ev, userID)) {
+				continue
+			}
+			outcome, err := UpsertResponse(ev.ID, userID, userID, reactionResponses[choice], -1)
+			if err != nil {
+				log.Printf("Failed to reconcile RSVP reaction: %v", err)
+				continue
+			}
+			announcePromotions(s, ev.ChannelID, outcome.Promoted)
+			changed = true
+		}
+		if changed {
+			refreshEventMessage(s, ev)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestReactionOrderMatchesResponses(t *testing.T) {
+	if len(reactionOrder) != len(reactionResponses) {
+		t.Fatalf("%d reactions seeded, %d mapped", len(reactionOrder), len(reactionResponses))
+	}
+	seen := map[string]bool{}
+	for _, emoji := range reactionOrder {
+		resp, ok := reactionResponses[emoji]
+		if !ok {
+			t.Errorf("seeded reaction %s has no response", emoji)
+		}
+		if seen[resp] {
+			t.Errorf("response %q mapped twice", resp)
+		}
+		seen[resp] = true
+	}
+	for _, resp := range []string{"yes", "maybe", "no"} {
+		if !seen[resp] {
+			t.Errorf("no reaction for %q", resp)
+		}
+	}
+}
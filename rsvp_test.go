@@ -0,0 +1,57 @@
+package main
+
+import "testing"
+
+func TestRSVPConfirmation(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		outcome  *RSVPOutcome
+		want     string
+	}{
+		{name: "no outcome", response: "maybe", want: "RSVP updated for <@1>: maybe"},
+		{name: "seated", response: "yes", outcome: &RSVPOutcome{}, want: "RSVP updated for <@1>: yes"},
+		{name: "waitlisted", response: "yes", outcome: &RSVPOutcome{Waitlisted: true}, want: "The event is full, so <@1> is on the waitlist."},
+		{name: "guests capped", response: "yes", outcome: &RSVPOutcome{GuestsCapped: true, Guests: 2}, want: "RSVP updated for <@1>: yes Plus-ones were lowered to the event's limit of 2."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rsvpConfirmation("<@1>", tt.response, tt.outcome); got != tt.want {
+				t.Errorf("rsvpConfirmation() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuestLimitError(t *testing.T) {
+	tests := []struct {
+		max, guests int
+		want        string
+	}{
+		{max: -1, guests: 12, want: ""},
+		{max: 2, guests: 2, want: ""},
+		{max: 2, guests: 3, want: "This event allows at most 2 plus-ones per person."},
+		{max: 0, guests: 0, want: ""},
+		{max: 0, guests: 1, want: "Plus-ones aren't allowed for this event."},
+	}
+	for _, tt := range tests {
+		if got := guestLimitError(&Event{MaxGuests: tt.max}, tt.guests); got != tt.want {
+			t.Errorf("guestLimitError(max %d, %d guests) = %q, want %q", tt.max, tt.guests, got, tt.want)
+		}
+	}
+}
+
+func TestCommentValue(t *testing.T) {
+	tests := map[string]string{
+		"arriving late": "arriving late",
+		"clear":         "",
+		"CLEAR":         "",
+		"clear skies":   "clear skies",
+		"":              "",
+	}
+	for in, want := range tests {
+		if got := commentValue(in); got != want {
+			t.Errorf("commentValue(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Public events are mirrored as Discord scheduled events. Scheduled events
+// are visible to the whole server, so private and role-restricted events are
+// not mirrored; making an event private removes its mirror. Members marking
+// themselves "Interested" get a maybe RSVP unless they already responded.
+
+// scheduledEventLifecycleInterval is how often mirrors are started and
+// completed as their events begin and end.
+const scheduledEventLifecycleInterval = 5 * time.Minute
+
+// defaultScheduledDuration stands in for the end of events without one;
+// Discord requires an end time for events outside voice channels.
+const defaultScheduledDuration = time.Hour
+
+// scheduledEventParams describes ev for Discord. The description links back
+// to the event channel and is cut to Discord's limits.
+func scheduledEventParams(ev *Event) *discordgo.GuildScheduledEventParams {
+	start := *ev.Date
+	end := start.Add(defaultScheduledDuration)
+	if ev.EndDate != nil && ev.EndDate.After(start) {
+		end = *ev.EndDate
+	}
+	location := ev.Location
+	if location == "" {
+		location = "TBD"
+	}
+	description := fmt.Sprintf("Details and RSVPs in <#%s>", ev.ChannelID)
+	if ev.Description != "" {
+		description = ev.Description + "\n\n" + description
+	}
+	return &discordgo.GuildScheduledEventParams{
+		Name:               truncateRunes(ev.Title, 100),
+		Description:        truncateRunes(description, 1000),
+		ScheduledStartTime: &start,
+		ScheduledEndTime:   &end,
+		PrivacyLevel:       discordgo.GuildScheduledEventPrivacyLevelGuildOnly,
+		EntityType:         discordgo.GuildScheduledEventEntityTypeExternal,
+		EntityMetadata:     &discordgo.GuildScheduledEventEntityMetadata{Location: truncateRunes(location, 100)},
+	}
+}
+
+// syncScheduledEvent creates, updates or removes the event's scheduled event
+// mirror to match it.
+func syncScheduledEvent(s *discordgo.Session, ev *Event) {
+	guildID := eventGuildID(s, ev)
+	if guildID == "" {
+		return
+	}
+	mirrored := ev.Visibility == "public" && ev.Date != nil
+	if ev.ScheduledEventID != "" && (!mirrored || ev.Cancelled()) {
+		if ev.Cancelled() && mirrored {
+			_, err := s.GuildScheduledEventEdit(guildID, ev.ScheduledEventID, &discordgo.GuildScheduledEventParams{Status: discordgo.GuildScheduledEventStatusCanceled})
+			if err == nil {
+				return
+			}
+			// only scheduled events can be cancelled; anything else is deleted
+		}
+		if err := s.GuildScheduledEventDelete(guildID, ev.ScheduledEventID); err != nil && !isNotFound(err) {
+			log.Printf("Failed to delete scheduled event: %v", err)
+			return
+		}
+		if err := UpdateEventFieldByChannel(ev.ChannelID, "scheduled_event_id", ""); err != nil {
+			log.Printf("Failed to clear scheduled event id: %v", err)
+		}
+		return
+	}
+	if !mirrored || ev.Cancelled() {
+		return
+	}
+
+	params := scheduledEventParams(ev)
+	if ev.ScheduledEventID != "" {
+		_, err := s.GuildScheduledEventEdit(guildID, ev.ScheduledEventID, params)
+		if err == nil {
+			return
+		}
+		if !isNotFound(err) {
+			log.Printf("Failed to update scheduled event: %v", err)
+			return
+		}
+		// deleted in Discord; create a fresh one below
+	}
+	if !ev.Date.After(time.Now()) {
+		// Discord only accepts scheduled events starting in the future
+		return
+	}
+	params.Status = discordgo.GuildScheduledEventStatusScheduled
+	se, err := s.GuildScheduledEventCreate(guildID, params)
+	if err != nil {
+		log.Printf("Failed to create scheduled event: %v", err)
+		return
+	}
+	if err := UpdateEventFieldByChannel(ev.ChannelID, "scheduled_event_id", se.ID); err != nil {
+		log.Printf("Failed to store scheduled event id: %v", err)
+	}
+}
+
+// runScheduledEventLifecycle starts mirrors when their event begins and
+// completes them when it ends, since Discord doesn't do that for events
+// outside voice channels.
+func runScheduledEventLifecycle(s *discordgo.Session, guildID string) {
+	ticker := time.NewTicker(scheduledEventLifecycleInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		events, err := ListScheduledEventMirrors()
+		if err != nil {
+			log.Printf("Failed to list scheduled event mirrors: %v", err)
+			continue
+		}
+		if len(events) == 0 {
+			continue
+		}
+		current, err := s.GuildScheduledEvents(guildID, false)
+		if err != nil {
+			log.Printf("Failed to list scheduled events: %v", err)
+			continue
+		}
+		status := map[string]discordgo.GuildScheduledEventStatus{}
+		for _, se := range current {
+			status[se.ID] = se.Status
+		}
+		now := time.Now()
+		for _, ev := range events {
+			st, ok := status[ev.ScheduledEventID]
+			if !ok || ev.Date == nil {
+				continue
+			}
+			next := st
+			switch {
+			case st == discordgo.GuildScheduledEventStatusActive && ev.End() != nil && !now.Before(*ev.End()):
+				next = discordgo.GuildScheduledEventStatusCompleted
+			case st == discordgo.GuildScheduledEventStatusScheduled && !now.Before(*ev.Date):
+				next = discordgo.GuildScheduledEventStatusActive
+			}
+			if next == st {
+				continue
+			}
+			if _, err := s.GuildScheduledEventEdit(guildID, ev.ScheduledEventID, &discordgo.GuildScheduledEventParams{Status: next}); err != nil {
+				log.Printf("Failed to move scheduled event %s along: %v", ev.ScheduledEventID, err)
+			}
+		}
+	}
+}
+
+func onGuildScheduledEventUserAdd(s *discordgo.Session, u *discordgo.GuildScheduledEventUserAdd) {
+	ev, err := GetEventByScheduledEvent(u.GuildScheduledEventID)
+	if err != nil {
+		return
+	}
+	if markInterested(s, ev, u.UserID) {
+		refreshEventMessage(s, ev)
+	}
+}
+
+func onGuildScheduledEventUserRemove(s *discordgo.Session, u *discordgo.GuildScheduledEventUserRemove) {
+	ev, err := GetEventByScheduledEvent(u.GuildScheduledEventID)
+	if err != nil || ev.Cancelled() || (ev.RSVPClosed() && !canBypassRSVPDeadline(s, ev, u.UserID)) {
+		return
+	}
+	// only a maybe mirrors "Interested"; other answers were given on purpose
+	current, err := GetUserResponse(ev.ID, u.UserID)
+	if err != nil || current != "maybe" {
+		return
+	}
+	promoted, err := DeleteResponse(ev.ID, u.UserID, u.UserID)
+	if err != nil {
+		log.Printf("Failed to remove RSVP (scheduled event): %v", err)
+		return
+	}
+	refreshEventMessage(s, ev)
+	announcePromotions(s, ev.ChannelID, promoted)
+}
+
+// onGuildScheduledEventDelete forgets a mirror deleted in Discord so it is
+// recreated on the next edit rather than failing.
+func onGuildScheduledEventDelete(s *discordgo.Session, d *discordgo.GuildScheduledEventDelete) {
+	if d.GuildScheduledEvent == nil {
+		return
+	}
+	ev, err := GetEventByScheduledEvent(d.ID)
+	if err != nil {
+		return
+	}
+	if err := UpdateEventFieldByChannel(ev.ChannelID, "scheduled_event_id", ""); err != nil {
+		log.Printf("Failed to clear scheduled event id: %v", err)
+	}
+}
+
+// markInterested records a maybe for a user who is interested in the mirror
+// and hasn't responded yet. It reports whether anything changed.
+func markInterested(s *discordgo.Session, ev *Event, userID string) bool {
+	if ev.Cancelled() || (ev.RSVPClosed() && !canBypassRSVPDeadline(s, ev, userID)) {
+		return false
+	}
+	current, err := GetUserResponse(ev.ID, userID)
+	if err != nil || current != "" {
+		return false
+	}
+	if _, err := UpsertResponse(ev.ID, userID, userID, "maybe", -1); err != nil {
+		log.Printf("Failed to persist RSVP (scheduled event): %v", err)
+		return false
+	}
+	return true
+}
+
+// reconcileScheduledEvents catches up on "Interested" clicks made while the
+// bot was offline.
+func reconcileScheduledEvents(s *discordgo.Session, guildID string) {
+	events, err := ListScheduledEventMirrors()
+	if err != nil {
+		log.Printf("Failed to list scheduled event mirrors: %v", err)
+		return
+	}
+	for _, ev := range events {
+		changed := false
+		after := ""
+		for {
+			page, err := s.GuildScheduledEventUsers(guildID, ev.ScheduledEventID, 100, false, "", after)
+			if err != nil {
+				log.Printf("Failed to fetch interested users for event %d: %v", ev.ID, err)
+				break
+			}
+			for _, u := range page {
+				if u.User != nil && markInterested(s, ev, u.User.ID) {
+					changed = true
+				}
+			}
+			if len(page) < 100 || page[len(page)-1].User == nil {
+				break
+			}
+			after = page[len(page)-1].User.ID
+		}
+		if changed {
+			refreshEventMessage(s, ev)
+		}
+	}
+}
+
+// eventGuildID finds the guild the event channel belongs to.
+func eventGuildID(s *discordgo.Session, ev *Event) string {
+	if ch, err := s.State.Channel(ev.ChannelID); err == nil {
+		return ch.GuildID
+	}
+	ch, err := s.Channel(ev.ChannelID)
+	if err != nil {
+		log.Printf("Failed to look up event channel: %v", err)
+		return ""
+	}
+	return ch.GuildID
+}
+
+func isNotFound(err error) bool {
+	var restErr *discordgo.RESTError
+	return errors.As(err, &restErr) && restErr.Response != nil && restErr.Response.StatusCode == http.StatusNotFound
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
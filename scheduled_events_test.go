@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScheduledEventParams(t *testing.T) {
+	start := time.Date(2025, 5, 2, 19, 0, 0, 0, time.UTC)
+	end := start.Add(3 * time.Hour)
+	before := start.Add(-time.Hour)
+	tests := []struct {
+		name         string
+		ev           *Event
+		wantEnd      time.Time
+		wantLocation string
+	}{
+		{name: "no end", ev: &Event{Title: "Poker", ChannelID: "9", Date: &start, Location: "Home"}, wantEnd: start.Add(defaultScheduledDuration), wantLocation: "Home"},
+		{name: "explicit end", ev: &Event{Title: "Poker", ChannelID: "9", Date: &start, EndDate: &end}, wantEnd: end, wantLocation: "TBD"},
+		{name: "end before start", ev: &Event{Title: "Poker", ChannelID: "9", Date: &start, EndDate: &before}, wantEnd: start.Add(defaultScheduledDuration), wantLocation: "TBD"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := scheduledEventParams(tt.ev)
+			if !p.ScheduledEndTime.Equal(tt.wantEnd) {
+				t.Errorf("end = %v, want %v", p.ScheduledEndTime, tt.wantEnd)
+			}
+			if p.EntityMetadata.Location != tt.wantLocation {
+				t.Errorf("location = %q, want %q", p.EntityMetadata.Location, tt.wantLocation)
+			}
+			if !strings.HasSuffix(p.Description, "<#9>") {
+				t.Errorf("description %q doesn't link the channel", p.Description)
+			}
+		})
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{s: "short", n: 10, want: "short"},
+		{s: "exact", n: 5, want: "exact"},
+		{s: "too long", n: 4, want: "too…"},
+		{s: "ééééé", n: 3, want: "éé…"},
+	}
+	for _, tt := range tests {
+		if got := truncateRunes(tt.s, tt.n); got != tt.want {
+			t.Errorf("truncateRunes(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// adminPermissions restricts commands to server admins by default.
+var adminPermissions int64 = discordgo.PermissionAdministrator
+
+func registerSettings(s *discordgo.Session, guildID string) {
+	cmd := &discordgo.ApplicationCommand{
+		Name:                     "settings",
+		Description:              "Admin only: view or change server-wide event settings",
+		DefaultMemberPermissions: &adminPermissions,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "proxy_rsvp",
+				Description: "Who may RSVP for someone else",
+				Required:    false,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "Organizers only", Value: "organizers"},
+					{Name: "Anyone, confirmed by the user via DM", Value: "anyone"},
+				},
+			},
+			{
+				Type:         discordgo.ApplicationCommandOptionChannel,
+				Name:         "announcements_channel",
+				Description:  "Where /event posts public announcement cards",
+				Required:     false,
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+			},
+		},
+	}
+	_, err := s.ApplicationCommandCreate(s.State.User.ID, guildID, cmd)
+	if err != nil {
+		log.Printf("Cannot create '/settings' command: %v", err)
+	}
+}
+
+func handleSettingsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	if i.ApplicationCommandData().Name != "settings" {
+		return
+	}
+	for _, opt := range i.ApplicationCommandData().Options {
+		var err error
+		switch opt.Name {
+		case "proxy_rsvp":
+			err = UpdateGuildSetting(i.GuildID, "open_proxy_rsvp", fmt.Sprint(opt.StringValue() == "anyone"))
+		case "announcements_channel":
+			err = UpdateGuildSetting(i.GuildID, "announcements_channel", fmt.Sprint(opt.Value))
+		}
+		if err != nil {
+			log.Printf("Failed to update guild setting %s: %v", opt.Name, err)
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{Content: "Failed to update settings.", Flags: discordgo.MessageFlagsEphemeral},
+			})
+			return
+		}
+	}
+
+	gs, err := GetGuildSettings(i.GuildID)
+	if err != nil {
+		log.Printf("Failed to load guild settings: %v", err)
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "Failed to load settings.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: formatGuildSettings(gs), Flags: discordgo.MessageFlagsEphemeral},
+	})
+}
+
+// formatGuildSettings lists the current settings for /settings.
+func formatGuildSettings(gs *GuildSettings) string {
+	var b strings.Builder
+	b.WriteString("**Server settings**\n")
+	proxy := "organizers only"
+	if gs.OpenProxyRSVP {
+		proxy = "anyone, confirmed by the user via DM"
+	}
+	fmt.Fprintf(&b, "Proxy RSVPs: %s\n", proxy)
+	announcements := "not set"
+	if gs.AnnouncementsChannelID != "" {
+		announcements = fmt.Sprintf("<#%s>", gs.AnnouncementsChannelID)
+	}
+	fmt.Fprintf(&b, "Announcements channel: %s\n", announcements)
+	return b.String()
+}
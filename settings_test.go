@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestFormatGuildSettings(t *testing.T) {
+	tests := []struct {
+		name string
+		gs   *GuildSettings
+		want string
+	}{
+		{name: "defaults", gs: &GuildSettings{}, want: "**Server settings**\nProxy RSVPs: organizers only\nAnnouncements channel: not set\n"},
+		{name: "open proxy", gs: &GuildSettings{OpenProxyRSVP: true}, want: "**Server settings**\nProxy RSVPs: anyone, confirmed by the user via DM\nAnnouncements channel: not set\n"},
+		{name: "announcements", gs: &GuildSettings{AnnouncementsChannelID: "42"}, want: "**Server settings**\nProxy RSVPs: organizers only\nAnnouncements channel: <#42>\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatGuildSettings(tt.gs); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
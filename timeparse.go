@@ -2,6 +2,7 @@ package main
 
 import (
     "fmt"
+    "os"
     "strings"
     "time"
 )
@@ -14,7 +15,8 @@ import (
 // - 2025-05-02 15:04
 // - 2025-05-02 15:04:05
 // Missing components default to the first valid value (start of period).
-// If no timezone is provided the input is interpreted in America/Chicago (Central Time).
+// If no timezone is provided the input is interpreted in defaultLocation
+// (Central Time unless EVENT_TIMEZONE says otherwise).
 func ParseFlexibleTime(input string) (time.Time, error) {
     s := strings.TrimSpace(input)
     if s == "" {
@@ -62,14 +64,10 @@ func ParseFlexibleTime(input string) (time.Time, error) {
     }
 
     // Build an RFC3339-like time without timezone info and parse it in the
-    // America/Chicago location so bare times are interpreted as Central Time.
+    // default location so bare times are interpreted in the event zone.
     combined := fmt.Sprintf("%s-%s-%sT%s:%s:%s", year, month, day, hour, min, sec)
 
-    loc, lerr := time.LoadLocation("America/Chicago")
-    if lerr != nil {
-        // if the zone database isn't available, fall back to local time
-        loc = time.Local
-    }
+    loc := defaultLocation()
 
     // Parse the combined time in the chosen location, then normalize to UTC
     // to keep the rest of the codebase consistent with previous behavior.
@@ -81,9 +79,123 @@ func ParseFlexibleTime(input string) (time.Time, error) {
     return t.UTC(), nil
 }
 
+// defaultLocation is the zone bare inputs are interpreted in and calendar
+// days are judged by: EVENT_TIMEZONE (an IANA name like Europe/Berlin) when
+// set, otherwise Central Time.
+func defaultLocation() *time.Location {
+    name := os.Getenv("EVENT_TIMEZONE")
+    if name == "" {
+        name = "America/Chicago"
+    }
+    loc, err := time.LoadLocation(name)
+    if err != nil {
+        // if the zone database isn't available, fall back to local time
+        return time.Local
+    }
+    return loc
+}
+
 func pad(s string, length int) string {
     if len(s) >= length {
         return s
     }
     return strings.Repeat("0", length-len(s)) + s
 }
+
+// ParseFlexibleRange accepts anything ParseFlexibleTime does, optionally
+// followed by an end separated by " to ":
+// - 2025-05-02 to 2025-05-04
+// - 2025-05-02 18:00 to 21:30
+// - 2025-05-02 18:00 to 2025-05-03 02:00
+// An end given as a bare date covers that whole day. An end given as a bare
+// time is taken on the start date, or the day after for overnight ranges like
+// 18:00 to 02:00. end is nil when no range was given.
+func ParseFlexibleRange(input string) (start time.Time, end *time.Time, err error) {
+    s := strings.TrimSpace(input)
+    idx := strings.Index(strings.ToLower(s), " to ")
+    if idx < 0 {
+        start, err = ParseFlexibleTime(s)
+        return start, nil, err
+    }
+    startStr := strings.TrimSpace(s[:idx])
+    endStr := strings.TrimSpace(s[idx+len(" to "):])
+    start, err = ParseFlexibleTime(startStr)
+    if err != nil {
+        return time.Time{}, nil, err
+    }
+    e, err := ParseEndInput(start, startStr, endStr)
+    if err != nil {
+        return time.Time{}, nil, err
+    }
+    return start, &e, nil
+}
+
+// ParseEndInput resolves the end of an event given its parsed start and the
+// raw start text. The end may be a duration (3h, 90m, 1h30m, 2d), a bare time
+// on the start date (21:30, or the next day when that's not after the start),
+// a bare date covering that whole day, month or year, or a full date and time.
+// The end must fall after the start.
+func ParseEndInput(start time.Time, startStr, endStr string) (time.Time, error) {
+    endStr = strings.TrimSpace(endStr)
+    if endStr == "" {
+        return time.Time{}, fmt.Errorf("empty end")
+    }
+    var end time.Time
+    if d, ok := parseDurationInput(endStr); ok {
+        end = start.Add(d)
+    } else if !strings.Contains(endStr, "-") && strings.Contains(endStr, ":") {
+        // bare time: reuse the start's date part
+        datePart := ""
+        if f := strings.Fields(startStr); len(f) > 0 {
+            datePart = f[0]
+        }
+        t, err := ParseFlexibleTime(datePart + " " + endStr)
+        if err != nil {
+            return time.Time{}, err
+        }
+        if !t.After(start) {
+            // an overnight range like 18:00 to 02:00 ends the next day
+            t = t.In(defaultLocation()).AddDate(0, 0, 1).UTC()
+        }
+        end = t
+    } else {
+        t, err := ParseFlexibleTime(endStr)
+        if err != nil {
+            return time.Time{}, err
+        }
+        if f := strings.Fields(endStr); len(f) == 1 {
+            // bare date: the event runs through the end of that period, a
+            // day, a month (2025-05) or a year (2025)
+            years, months, days := 0, 0, 1
+            switch len(strings.Split(f[0], "-")) {
+            case 1:
+                years, days = 1, 0
+            case 2:
+                months, days = 1, 0
+            }
+            t = t.In(defaultLocation()).AddDate(years, months, days).Add(-time.Second).UTC()
+        }
+        end = t
+    }
+    if !end.After(start) {
+        return time.Time{}, fmt.Errorf("end must be after start")
+    }
+    return end, nil
+}
+
+// parseDurationInput accepts Go durations (3h, 90m, 1h30m) plus a whole-day
+// suffix like 2d.
+func parseDurationInput(s string) (time.Duration, bool) {
+    if strings.HasSuffix(s, "d") {
+        var days int
+        if _, err := fmt.Sscanf(s, "%dd", &days); err == nil && days > 0 {
+            return time.Duration(days) * 24 * time.Hour, true
+        }
+        return 0, false
+    }
+    d, err := time.ParseDuration(s)
+    if err != nil || d <= 0 {
+        return 0, false
+    }
+    return d, true
+}
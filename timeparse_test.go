@@ -0,0 +1,143 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// central parses "2006-01-02 15:04:05" as Central Time, like bare inputs.
+func central(t *testing.T, s string) time.Time {
+	t.Helper()
+	loc, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	v, err := time.ParseInLocation("2006-01-02 15:04:05", s, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v.UTC()
+}
+
+func TestParseFlexibleRange(t *testing.T) {
+	tests := []struct {
+		in       string
+		start    string
+		end      string // "" for no end
+		wantFail bool
+	}{
+		{in: "2025", start: "2025-01-01 00:00:00"},
+		{in: "2025-05", start: "2025-05-01 00:00:00"},
+		{in: "2025-05-02 18:30", start: "2025-05-02 18:30:00"},
+		{in: "2025-05-02 18:00 to 21:30", start: "2025-05-02 18:00:00", end: "2025-05-02 21:30:00"},
+		{in: "2025-05-02 18:00 TO 21:30", start: "2025-05-02 18:00:00", end: "2025-05-02 21:30:00"},
+		{in: "2025-05-02 to 2025-05-04", start: "2025-05-02 00:00:00", end: "2025-05-04 23:59:59"},
+		{in: "2025-05-02 18:00 to 2025-05-03 02:00", start: "2025-05-02 18:00:00", end: "2025-05-03 02:00:00"},
+		{in: "2025-05-02 18:00 to 02:00", start: "2025-05-02 18:00:00", end: "2025-05-03 02:00:00"},
+		{in: "2025-05-02 18:00 to 3h", start: "2025-05-02 18:00:00", end: "2025-05-02 21:00:00"},
+		{in: "2025-05-02 to 2025-05", start: "2025-05-02 00:00:00", end: "2025-05-31 23:59:59"},
+		{in: "2025-05-02 18:00 to 2025-05-01", wantFail: true},
+		{in: "2025-05-02 to nonsense", wantFail: true},
+		{in: "", wantFail: true},
+		{in: "tomorrow", wantFail: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			start, end, err := ParseFlexibleRange(tt.in)
+			if tt.wantFail {
+				if err == nil {
+					t.Fatalf("got %v, %v; want an error", start, end)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if want := central(t, tt.start); !start.Equal(want) {
+				t.Errorf("start = %v, want %v", start, want)
+			}
+			switch {
+			case tt.end == "" && end != nil:
+				t.Errorf("end = %v, want none", *end)
+			case tt.end != "" && end == nil:
+				t.Errorf("end = none, want %s", tt.end)
+			case tt.end != "" && !end.Equal(central(t, tt.end)):
+				t.Errorf("end = %v, want %v", *end, central(t, tt.end))
+			}
+		})
+	}
+}
+
+func TestParseEndInput(t *testing.T) {
+	tests := []struct {
+		startStr string
+		endStr   string
+		want     string
+		wantFail bool
+	}{
+		{startStr: "2025-05-02 18:00", endStr: "21:30", want: "2025-05-02 21:30:00"},
+		{startStr: "2025-05-02 18:00", endStr: "18:00", want: "2025-05-03 18:00:00"},
+		{startStr: "2025-05-02 22:00", endStr: "01:15", want: "2025-05-03 01:15:00"},
+		{startStr: "2025-05-02 18:00", endStr: "90m", want: "2025-05-02 19:30:00"},
+		{startStr: "2025-05-02 18:00", endStr: "1h30m", want: "2025-05-02 19:30:00"},
+		{startStr: "2025-05-02 18:00", endStr: "2d", want: "2025-05-04 18:00:00"},
+		{startStr: "2025-05-02 18:00", endStr: "2025-05-02", want: "2025-05-02 23:59:59"},
+		{startStr: "2025-05-02 18:00", endStr: "2025-05-05 10:00", want: "2025-05-05 10:00:00"},
+		{startStr: "2025-05-02", endStr: "2025-05", want: "2025-05-31 23:59:59"},
+		{startStr: "2025-02-10", endStr: "2025-02", want: "2025-02-28 23:59:59"},
+		{startStr: "2025-05-02", endStr: "2025", want: "2025-12-31 23:59:59"},
+		// overnight across the start of DST
+		{startStr: "2025-03-08 22:00", endStr: "03:30", want: "2025-03-09 03:30:00"},
+		{startStr: "2025-05-02 18:00", endStr: "2025-05-01 10:00", wantFail: true},
+		{startStr: "2025-05-02 18:00", endStr: "", wantFail: true},
+		{startStr: "2025-05-02 18:00", endStr: "0h", wantFail: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.startStr+" to "+tt.endStr, func(t *testing.T) {
+			start, err := ParseFlexibleTime(tt.startStr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ParseEndInput(start, tt.startStr, tt.endStr)
+			if tt.wantFail {
+				if err == nil {
+					t.Fatalf("got %v; want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if want := central(t, tt.want); !got.Equal(want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseDurationInput(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"3h", 3 * time.Hour, true},
+		{"90m", 90 * time.Minute, true},
+		{"1h30m", 90 * time.Minute, true},
+		{"2d", 48 * time.Hour, true},
+		{"0d", 0, false},
+		{"-1h", 0, false},
+		{"0s", 0, false},
+		{"d", 0, false},
+		{"21:30", 0, false},
+		{"2025-05-02", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, ok := parseDurationInput(tt.in)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("parseDurationInput(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
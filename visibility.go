@@ -0,0 +1,242 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// visibilityChoices are the values accepted by the visibility options.
+var visibilityChoices = []*discordgo.ApplicationCommandOptionChoice{
+	{Name: "Public (everyone can view)", Value: "public"},
+	{Name: "Private (invite only)", Value: "private"},
+	{Name: "Roles (only the given roles)", Value: "roles"},
+}
+
+// parseVisibility validates a visibility choice and the role mentions that go
+// with it. An empty choice means private, the original behaviour.
+func parseVisibility(visibility, rolesStr string) (string, []string, error) {
+	if visibility == "" {
+		visibility = "private"
+	}
+	var roles []string
+	for _, m := range roleMentionRe.FindAllStringSubmatch(rolesStr, -1) {
+		roles = append(roles, m[1])
+	}
+	switch visibility {
+	case "public", "private":
+		return visibility, nil, nil
+	case "roles":
+		if len(roles) == 0 {
+			return "", nil, fmt.Errorf("mention at least one role for a role-restricted event")
+		}
+		return visibility, roles, nil
+	}
+	return "", nil, fmt.Errorf("unknown visibility %q", visibility)
+}
+
+// everyoneOverwrite decides whether @everyone can see the channel. Public
+// events allow it explicitly so the category's permissions don't hide them.
+func everyoneOverwrite(guildID, visibility string) *discordgo.PermissionOverwrite {
+	ow := &discordgo.PermissionOverwrite{ID: guildID, Type: discordgo.PermissionOverwriteTypeRole}
+	if visibility == "public" {
+		ow.Allow = discordgo.PermissionViewChannel
+	} else {
+		ow.Deny = discordgo.PermissionViewChannel
+	}
+	return ow
+}
+
+// visibilityOverwrites builds the @everyone and role overwrites for a new
+// event channel.
+func visibilityOverwrites(guildID, visibility string, roles []string) []*discordgo.PermissionOverwrite {
+	out := []*discordgo.PermissionOverwrite{everyoneOverwrite(guildID, visibility)}
+	for _, r := range roles {
+		out = append(out, inviteOverwrite(EventInvite{TargetID: r, TargetType: "role"}))
+	}
+	return out
+}
+
+// applyVisibility updates the channel overwrites of an existing event.
+// Roles dropped from a role-restricted event lose access unless they are
+// also on the invite roster.
+func applyVisibility(s *discordgo.Session, ev *Event, guildID, visibility string, roles []string) error {
+	ow := everyoneOverwrite(guildID, visibility)
+	if err := s.ChannelPermissionSet(ev.ChannelID, ow.ID, ow.Type, ow.Allow, ow.Deny); err != nil {
+		return err
+	}
+	keep := map[string]bool{}
+	for _, r := range roles {
+		keep[r] = true
+	}
+	invites, err := GetInvites(ev.ID)
+	if err != nil {
+		return err
+	}
+	for _, inv := range invites {
+		keep[inv.TargetID] = true
+	}
+	for _, r := range ev.VisibilityRoles {
+		if keep[r] {
+			continue
+		}
+		if err := s.ChannelPermissionDelete(ev.ChannelID, r); err != nil {
+			log.Printf("Failed to remove role overwrite: %v", err)
+		}
+	}
+	for _, r := range roles {
+		ow := inviteOverwrite(EventInvite{TargetID: r, TargetType: "role"})
+		if err := s.ChannelPermissionSet(ev.ChannelID, ow.ID, ow.Type, ow.Allow, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// canViewEvent reports whether member can see the event channel, going by
+// its visibility, organizers and invite roster. Admins can see everything.
+func canViewEvent(ev *Event, member *discordgo.Member) bool {
+	if member == nil || member.User == nil {
+		return false
+	}
+	if ev.Visibility == "public" || member.Permissions&discordgo.PermissionAdministrator != 0 || isEventOrganizer(ev, member.User.ID) {
+		return true
+	}
+	has := map[string]bool{member.User.ID: true}
+	for _, r := range member.Roles {
+		has[r] = true
+	}
+	for _, r := range ev.VisibilityRoles {
+		if has[r] {
+			return true
+		}
+	}
+	invites, err := GetInvites(ev.ID)
+	if err != nil {
+		log.Printf("Failed to load invites: %v", err)
+		return false
+	}
+	for _, inv := range invites {
+		if has[inv.TargetID] {
+			return true
+		}
+	}
+	return false
+}
+
+// canUserViewEvent is canViewEvent for callers without an interaction, which
+// have to look the member up first.
+func canUserViewEvent(s *discordgo.Session, ev *Event, guildID, userID string) (bool, error) {
+	member, err := s.GuildMember(guildID, userID)
+	if err != nil {
+		return false, err
+	}
+	// members fetched outside an interaction carry no permissions
+	if perms, err := s.UserChannelPermissions(userID, ev.ChannelID); err == nil {
+		member.Permissions = perms
+	}
+	return canViewEvent(ev, member), nil
+}
+
+// describeVisibility renders a visibility setting for replies.
+func describeVisibility(visibility string, roles []string) string {
+	switch visibility {
+	case "public":
+		return "public"
+	case "roles":
+		mentions := make([]string, len(roles))
+		for i, r := range roles {
+			mentions[i] = fmt.Sprintf("<@&%s>", r)
+		}
+		return "visible to " + strings.Join(mentions, " ")
+	}
+	return "private (invite only)"
+}
+
+// Register and handle change_visibility
+func registerChangeVisibility(s *discordgo.Session, guildID string) {
+	cmd := &discordgo.ApplicationCommand{
+		Name:        "change_visibility",
+		Description: "Change who can see the event in the current channel",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "visibility",
+				Description: "Who can see the event channel",
+				Required:    true,
+				Choices:     visibilityChoices,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "roles",
+				Description: "Roles that can see a role-restricted event (e.g. @members @friends)",
+				Required:    false,
+			},
+		},
+	}
+	_, err := s.ApplicationCommandCreate(s.State.User.ID, guildID, cmd)
+	if err != nil {
+		log.Printf("Cannot create '/change_visibility' command: %v", err)
+	}
+}
+
+func handleChangeVisibilityCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	if i.ApplicationCommandData().Name != "change_visibility" {
+		return
+	}
+	ev := requireEventManager(s, i)
+	if ev == nil {
+		return
+	}
+	var visibilityStr, rolesStr string
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "visibility":
+			visibilityStr = opt.StringValue()
+		case "roles":
+			rolesStr = opt.StringValue()
+		}
+	}
+	visibility, roles, err := parseVisibility(visibilityStr, rolesStr)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "Please " + err.Error() + ".", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+
+	if err := applyVisibility(s, ev, i.GuildID, visibility, roles); err != nil {
+		log.Printf("Failed to update channel permissions: %v", err)
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "Failed to update channel permissions.", Flags: discordgo.MessageFlagsEphemeral},
+		})
+		return
+	}
+	if err := UpdateEventFieldByChannel(ev.ChannelID, "visibility", visibility); err != nil {
+		log.Printf("Failed to update event visibility in DB: %v", err)
+	}
+	if err := UpdateEventFieldByChannel(ev.ChannelID, "visibility_roles", strings.Join(roles, " ")); err != nil {
+		log.Printf("Failed to update event visibility roles in DB: %v", err)
+	}
+	if ev, err := GetEventByChannel(ev.ChannelID); err == nil {
+		// people the change locks out shouldn't keep their seats
+		promoted := dropResponsesWithoutAccess(s, ev, i.GuildID, i.Member.User.ID)
+		refreshEventMessage(s, ev)
+		announcePromotions(s, ev.ChannelID, promoted)
+		syncEventMirrors(s, ev)
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:         "Event is now " + describeVisibility(visibility, roles) + ".",
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
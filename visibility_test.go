@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestParseVisibility(t *testing.T) {
+	tests := []struct {
+		visibility string
+		roles      string
+		want       string
+		wantRoles  []string
+		wantFail   bool
+	}{
+		{visibility: "", want: "private"},
+		{visibility: "public", roles: "<@&1>", want: "public"},
+		{visibility: "private", want: "private"},
+		{visibility: "roles", roles: "<@&1> <@&2>", want: "roles", wantRoles: []string{"1", "2"}},
+		{visibility: "roles", roles: "<@3>", wantFail: true},
+		{visibility: "roles", wantFail: true},
+		{visibility: "secret", wantFail: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.visibility+" "+tt.roles, func(t *testing.T) {
+			got, roles, err := parseVisibility(tt.visibility, tt.roles)
+			if tt.wantFail {
+				if err == nil {
+					t.Fatalf("got %q, %v; want an error", got, roles)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want || strings.Join(roles, ",") != strings.Join(tt.wantRoles, ",") {
+				t.Errorf("got %q, %v; want %q, %v", got, roles, tt.want, tt.wantRoles)
+			}
+		})
+	}
+}
+
+func TestCanViewEvent(t *testing.T) {
+	member := func(id string, perms int64) *discordgo.Member {
+		return &discordgo.Member{User: &discordgo.User{ID: id}, Permissions: perms}
+	}
+	tests := []struct {
+		name   string
+		ev     *Event
+		member *discordgo.Member
+		want   bool
+	}{
+		{name: "public", ev: &Event{ID: 1, AuthorID: "author", Visibility: "public"}, member: member("someone", 0), want: true},
+		{name: "admin", ev: &Event{ID: 1, AuthorID: "author", Visibility: "private"}, member: member("someone", discordgo.PermissionAdministrator), want: true},
+		{name: "author", ev: &Event{ID: 1, AuthorID: "author", Visibility: "private"}, member: member("author", 0), want: true},
+		{name: "no member", ev: &Event{ID: 1, AuthorID: "author", Visibility: "public"}, member: nil, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canViewEvent(tt.ev, tt.member); got != tt.want {
+				t.Errorf("canViewEvent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}